@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"os"
+	"strconv"
 	"telemetry-api/internal/database"
+	"telemetry-api/internal/export"
 	"telemetry-api/internal/handlers"
 	"telemetry-api/internal/middleware"
+	"telemetry-api/internal/models"
 	"telemetry-api/internal/observability"
+	"telemetry-api/internal/realtime"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/websocket/v2"
 )
 
@@ -38,11 +44,77 @@ func main() {
 	}
 	defer db.Close()
 
-	h := handlers.NewHandlers(db)
+	listener, err := realtime.NewListener(database.ConnString(
+		os.Getenv("DB_HOST"),
+		5432,
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+	))
+	if err != nil {
+		log.Fatalf("Failed to start notification listener: %v", err)
+	}
+	defer listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go listener.Run(ctx)
+
+	hub := realtime.NewHub(listener)
+	go hub.Run(ctx)
+
+	// SIGNAL_REFERENCE_DBM, the power in dBm of a 0 dB signal reading,
+	// enables units=signal:dBm.
+	if value := os.Getenv("SIGNAL_REFERENCE_DBM"); value != "" {
+		reference, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Fatalf("Invalid SIGNAL_REFERENCE_DBM: %v", err)
+		}
+		models.SetSignalReference(reference)
+	}
+
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "exports"
+	}
+	exportRetention := 24 * time.Hour
+	if value := os.Getenv("EXPORT_RETENTION"); value != "" {
+		exportRetention, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid EXPORT_RETENTION: %v", err)
+		}
+	}
+
+	exports, err := export.NewManager(db, exportDir, exportRetention)
+	if err != nil {
+		log.Fatalf("Failed to start export worker: %v", err)
+	}
+	go exports.Run(ctx)
+
+	var maxRange time.Duration
+	if value := os.Getenv("MAX_QUERY_RANGE"); value != "" {
+		maxRange, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid MAX_QUERY_RANGE: %v", err)
+		}
+	}
+
+	// MISSION_EPOCH (RFC 3339, UTC) enables time_system=MET.
+	var missionEpoch time.Time
+	if value := os.Getenv("MISSION_EPOCH"); value != "" {
+		missionEpoch, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Fatalf("Invalid MISSION_EPOCH: %v", err)
+		}
+	}
+
+	h := handlers.NewHandlers(db, listener, exports, maxRange, missionEpoch)
 
 	app := fiber.New(fiber.Config{
 		JSONEncoder: json.Marshal,
 		JSONDecoder: json.Unmarshal,
+		// Lets list parameters such as subsystem_id=1,2 bind to slice fields.
+		EnableSplittingOnParsers: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			log.Printf("Error handling request: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -51,6 +123,7 @@ func main() {
 		},
 	})
 
+	app.Use(recover.New())
 	app.Use(cors.New())
 	app.Use(middleware.TracingMiddleware())
 	app.Use(logger.New(logger.Config{
@@ -62,7 +135,27 @@ func main() {
 	api := app.Group("/api/v1")
 	api.Get("/telemetry", h.GetTelemetry)
 	api.Get("/telemetry/current", h.GetCurrentTelemetry)
+	api.Get("/telemetry/at", h.GetTelemetryAt)
+	api.Get("/telemetry/aggregates", h.GetAggregates)
+	api.Get("/telemetry/series", h.GetSeries)
+	api.Get("/telemetry/stats", h.GetStats)
+	api.Get("/telemetry/coverage", h.GetCoverage)
+	api.Get("/telemetry/compare", h.CompareTelemetry)
+	api.Get("/telemetry/correlation", h.GetCorrelation)
+	api.Get("/telemetry/forecast", h.GetForecast)
+	api.Get("/telemetry/spectrum", h.GetSpectrum)
+	api.Get("/telemetry/histogram", h.GetHistogram)
 	api.Get("/telemetry/anomalies", h.GetAnomalies)
+	api.Get("/telemetry/stream", h.StreamTelemetry)
+	api.Get("/anomalies/stream", h.StreamAnomalies)
+	api.Get("/anomalies/summary", h.GetAnomalySummary)
+	api.Get("/telemetry/export", h.ExportTelemetry)
+	api.Get("/anomalies/export", h.ExportAnomalies)
+	api.Post("/exports", h.CreateExport)
+	api.Get("/exports/:id", h.GetExport)
+	api.Delete("/exports/:id", h.CancelExport)
+	api.Get("/exports/:id/download", h.DownloadExport)
+	api.Get("/subsystems", h.GetSubsystems)
 
 	app.Use("/ws", func(c *fiber.Ctx) error {
 		if websocket.IsWebSocketUpgrade(c) {
@@ -72,22 +165,8 @@ func main() {
 		return fiber.ErrUpgradeRequired
 	})
 
-	app.Get("/ws", websocket.New(func(c *websocket.Conn) {
-		for {
-			record, err := db.GetCurrentTelemetry()
-			if err != nil {
-				log.Printf("Error getting current telemetry: %v", err)
-				continue
-			}
-
-			if err := c.WriteJSON(record); err != nil {
-				log.Printf("Error writing to websocket: %v", err)
-				break
-			}
-
-			time.Sleep(1 * time.Second)
-		}
-	}))
+	app.Get("/ws", websocket.New(hub.ServeWS))
+	app.Get("/ws/playback", h.PlaybackWindow, websocket.New(realtime.NewPlayback(db).Serve))
 
 	port := os.Getenv("PORT")
 	if port == "" {
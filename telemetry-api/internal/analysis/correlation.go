@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Lag is the Pearson correlation of x[i] with y[i+Lag]; a positive lag means
+// y follows x. R is NaN when it is undefined, e.g. for a constant series.
+type Lag struct {
+	Lag   int
+	R     float64
+	Pairs int
+}
+
+// Pearson returns the Pearson correlation of the pairs where both x and y are
+// present (not NaN), and how many pairs that was. The result is NaN for
+// fewer than two pairs or when either side is constant.
+func Pearson(x, y []float64) (float64, int) {
+	x, y = pairs(x, y, 0)
+	return pearson(x, y), len(x)
+}
+
+// Spearman returns the Spearman rank correlation of the pairs where both x
+// and y are present, ranking ties by their average rank.
+func Spearman(x, y []float64) (float64, int) {
+	x, y = pairs(x, y, 0)
+	return pearson(ranks(x), ranks(y)), len(x)
+}
+
+// CrossCorrelation returns the Pearson correlation at every lag from -maxLag
+// to maxLag.
+func CrossCorrelation(x, y []float64, maxLag int) []Lag {
+	lags := make([]Lag, 0, 2*maxLag+1)
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		px, py := pairs(x, y, lag)
+		lags = append(lags, Lag{Lag: lag, R: pearson(px, py), Pairs: len(px)})
+	}
+	return lags
+}
+
+// pairs returns x[i] and y[i+lag] for every i where both are present.
+func pairs(x, y []float64, lag int) ([]float64, []float64) {
+	var px, py []float64
+	for i := range x {
+		j := i + lag
+		if j < 0 || j >= len(y) || math.IsNaN(x[i]) || math.IsNaN(y[j]) {
+			continue
+		}
+		px = append(px, x[i])
+		py = append(py, y[j])
+	}
+	return px, py
+}
+
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	if len(x) < 2 {
+		return math.NaN()
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// ranks returns the 1-based rank of each value, averaging the ranks of ties.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	result := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			result[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return result
+}
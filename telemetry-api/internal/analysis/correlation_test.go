@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPearson(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name  string
+		x, y  []float64
+		want  float64 // NaN when undefined
+		pairs int
+	}{
+		{name: "perfect", x: []float64{1, 2, 3, 4}, y: []float64{3, 5, 7, 9}, want: 1, pairs: 4},
+		{name: "inverse", x: []float64{1, 2, 3, 4}, y: []float64{8, 6, 4, 2}, want: -1, pairs: 4},
+		{name: "partial", x: []float64{1, 2, 3, 4, 5}, y: []float64{2, 1, 4, 3, 5}, want: 0.8, pairs: 5},
+		{name: "missing values skipped", x: []float64{1, nan, 2, 3}, y: []float64{2, 100, 4, nan}, want: 1, pairs: 2},
+		{name: "constant", x: []float64{1, 2, 3}, y: []float64{5, 5, 5}, want: nan, pairs: 3},
+		{name: "single pair", x: []float64{1}, y: []float64{2}, want: nan, pairs: 1},
+		{name: "empty", want: nan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pairs := Pearson(tt.x, tt.y)
+			if pairs != tt.pairs {
+				t.Errorf("got %d pairs, want %d", pairs, tt.pairs)
+			}
+			if !closeOrNaN(got, tt.want) {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpearman(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name  string
+		x, y  []float64
+		want  float64
+		pairs int
+	}{
+		{name: "monotonic", x: []float64{1, 2, 3, 4, 5}, y: []float64{1, 8, 27, 64, 125}, want: 1, pairs: 5},
+		{name: "reversed", x: []float64{1, 2, 3, 4}, y: []float64{10, 1, 0.5, -3}, want: -1, pairs: 4},
+		// Ranks are x [1 2.5 2.5 4] and y [1 3 2 4]: 4.5 / sqrt(4.5 * 5).
+		{name: "ties in x", x: []float64{1, 2, 2, 3}, y: []float64{1, 3, 2, 4}, want: 4.5 / math.Sqrt(22.5), pairs: 4},
+		// Ranks are x [1.5 1.5 3.5 3.5] and y [1.5 3.5 1.5 3.5].
+		{name: "ties on both sides", x: []float64{0, 0, 1, 1}, y: []float64{5, 7, 5, 7}, want: 0, pairs: 4},
+		{name: "ties matching", x: []float64{1, 1, 2, 3, 3}, y: []float64{4, 4, 6, 9, 9}, want: 1, pairs: 5},
+		{name: "all tied", x: []float64{2, 2, 2}, y: []float64{1, 2, 3}, want: nan, pairs: 3},
+		{name: "missing values skipped", x: []float64{1, 2, nan, 3}, y: []float64{10, 20, 0, 30}, want: 1, pairs: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pairs := Spearman(tt.x, tt.y)
+			if pairs != tt.pairs {
+				t.Errorf("got %d pairs, want %d", pairs, tt.pairs)
+			}
+			if !closeOrNaN(got, tt.want) {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRanks(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   []float64
+	}{
+		{values: []float64{30, 10, 20}, want: []float64{3, 1, 2}},
+		{values: []float64{10, 20, 20, 30, 20}, want: []float64{1, 3, 3, 5, 3}},
+		{values: []float64{7, 7, 7, 7}, want: []float64{2.5, 2.5, 2.5, 2.5}},
+		{values: []float64{}, want: []float64{}},
+	}
+
+	for _, tt := range tests {
+		if got := ranks(tt.values); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ranks(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestCrossCorrelation(t *testing.T) {
+	// y repeats x two samples later.
+	x := []float64{0, 1, 0, 3, 1, 4, 2, 0, 5, 1}
+	y := append([]float64{9, 9}, x[:len(x)-2]...)
+
+	lags := CrossCorrelation(x, y, 3)
+	if len(lags) != 7 {
+		t.Fatalf("got %d lags, want 7", len(lags))
+	}
+	for _, lag := range lags {
+		if lag.Lag == 2 {
+			if math.Abs(lag.R-1) > 1e-9 || lag.Pairs != 8 {
+				t.Errorf("lag 2: got r=%g over %d pairs, want 1 over 8", lag.R, lag.Pairs)
+			}
+		} else if lag.R >= 0.99 {
+			t.Errorf("lag %d: got r=%g, want below 0.99", lag.Lag, lag.R)
+		}
+	}
+}
+
+func closeOrNaN(got, want float64) bool {
+	if math.IsNaN(want) {
+		return math.IsNaN(got)
+	}
+	return math.Abs(got-want) < 1e-9
+}
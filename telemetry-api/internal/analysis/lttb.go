@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// LTTB downsamples points (sorted by X) to at most threshold points using the
+// Largest-Triangle-Three-Buckets algorithm and returns the indices kept. The
+// first and last points are always kept, so thresholds below 2 keep both.
+func LTTB(points []Point, threshold int) []int {
+	n := len(points)
+	if threshold >= n || n <= 2 {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+	if threshold <= 2 {
+		return []int{0, n - 1}
+	}
+
+	indices := make([]int, 0, threshold)
+	indices = append(indices, 0)
+
+	// Interior points are split into threshold-2 buckets.
+	every := float64(n-2) / float64(threshold-2)
+	a := 0
+
+	for i := 0; i < threshold-2; i++ {
+		// Average of the next bucket is the third triangle vertex.
+		nextStart := int(math.Floor(float64(i+1)*every)) + 1
+		nextEnd := int(math.Floor(float64(i+2)*every)) + 1
+		if nextEnd > n {
+			nextEnd = n
+		}
+		var avgX, avgY float64
+		for j := nextStart; j < nextEnd; j++ {
+			avgX += points[j].X
+			avgY += points[j].Y
+		}
+		if count := float64(nextEnd - nextStart); count > 0 {
+			avgX /= count
+			avgY /= count
+		}
+
+		start := int(math.Floor(float64(i)*every)) + 1
+		end := int(math.Floor(float64(i+1)*every)) + 1
+
+		maxArea := -1.0
+		selected := start
+		for j := start; j < end; j++ {
+			area := math.Abs((points[a].X-avgX)*(points[j].Y-points[a].Y) -
+				(points[a].X-points[j].X)*(avgY-points[a].Y))
+			if area > maxArea {
+				maxArea = area
+				selected = j
+			}
+		}
+
+		indices = append(indices, selected)
+		a = selected
+	}
+
+	return append(indices, n-1)
+}
+
+// LTTBWithForced downsamples like LTTB but always keeps the forced indices,
+// shrinking the LTTB budget to make room for them. The LTTB budget never drops
+// below the first and last points, so when forced points take up most of the
+// threshold the result exceeds it.
+func LTTBWithForced(points []Point, threshold int, forced []int) []int {
+	if len(forced) == 0 {
+		return LTTB(points, threshold)
+	}
+
+	budget := threshold - len(forced)
+	if budget < 2 {
+		budget = 2
+	}
+
+	kept := make(map[int]struct{}, threshold)
+	for _, i := range LTTB(points, budget) {
+		kept[i] = struct{}{}
+	}
+	for _, i := range forced {
+		kept[i] = struct{}{}
+	}
+
+	indices := make([]int, 0, len(kept))
+	for i := range kept {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
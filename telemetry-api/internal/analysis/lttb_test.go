@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func sine(n int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{X: float64(i), Y: math.Sin(float64(i) / 5)}
+	}
+	return points
+}
+
+func TestLTTB(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    int
+		threshold int
+		want      []int // nil to check only the length and endpoints
+		length    int
+	}{
+		{name: "empty", points: 0, threshold: 10, want: []int{}},
+		{name: "single point", points: 1, threshold: 0, want: []int{0}},
+		{name: "threshold above length", points: 5, threshold: 10, want: []int{0, 1, 2, 3, 4}},
+		{name: "threshold equals length", points: 5, threshold: 5, want: []int{0, 1, 2, 3, 4}},
+		{name: "negative threshold", points: 50, threshold: -1, want: []int{0, 49}},
+		{name: "zero threshold", points: 50, threshold: 0, want: []int{0, 49}},
+		{name: "threshold of one", points: 50, threshold: 1, want: []int{0, 49}},
+		{name: "threshold of two", points: 50, threshold: 2, want: []int{0, 49}},
+		{name: "threshold of three", points: 50, threshold: 3, length: 3},
+		{name: "downsampled", points: 1000, threshold: 100, length: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LTTB(sine(tt.points), tt.threshold)
+			if tt.want != nil {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if len(got) != tt.length {
+				t.Fatalf("got %d points, want %d", len(got), tt.length)
+			}
+			if got[0] != 0 || got[len(got)-1] != tt.points-1 {
+				t.Errorf("endpoints not kept: %v", got)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i] <= got[i-1] {
+					t.Fatalf("indices not increasing: %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestLTTBWithForced(t *testing.T) {
+	many := make([]int, 0, 60)
+	for i := 10; i < 70; i++ {
+		many = append(many, i)
+	}
+
+	tests := []struct {
+		name      string
+		threshold int
+		forced    []int
+		length    int
+	}{
+		{name: "no forced points", threshold: 20, length: 20},
+		{name: "forced within budget", threshold: 20, forced: []int{33, 47, 81}, length: 20},
+		{name: "forced fill the budget", threshold: 5, forced: []int{33, 47, 81, 90, 91}, length: 7},
+		{name: "forced beyond budget", threshold: 20, forced: many, length: 62},
+		{name: "zero threshold", threshold: 0, forced: []int{50}, length: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LTTBWithForced(sine(100), tt.threshold, tt.forced)
+			if len(got) != tt.length {
+				t.Fatalf("got %d points, want %d: %v", len(got), tt.length, got)
+			}
+
+			kept := make(map[int]bool, len(got))
+			for i, index := range got {
+				if i > 0 && index <= got[i-1] {
+					t.Fatalf("indices not increasing: %v", got)
+				}
+				kept[index] = true
+			}
+			for _, i := range append([]int{0, 99}, tt.forced...) {
+				if !kept[i] {
+					t.Errorf("point %d not kept", i)
+				}
+			}
+		})
+	}
+}
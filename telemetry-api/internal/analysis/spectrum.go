@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// FFT computes the discrete Fourier transform in place with the iterative
+// radix-2 Cooley-Tukey algorithm. len(x) must be a power of two.
+func FFT(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation.
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], w*x[start+k+size/2]
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// NextPowerOfTwo returns the smallest power of two not less than n.
+func NextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// Periodogram estimates the one-sided power spectral density of evenly
+// spaced values sampled at sampleRate (Hz). The series is detrended and
+// Hann-windowed, then zero-padded to a power of two. It returns the
+// frequency of each bin in Hz and its density in units² per Hz.
+func Periodogram(values []float64, sampleRate float64) ([]float64, []float64) {
+	n := len(values)
+	if n < 2 {
+		return nil, nil
+	}
+
+	detrended := detrend(values)
+	size := NextPowerOfTwo(n)
+	x := make([]complex128, size)
+	var windowPower float64
+	for i, v := range detrended {
+		w := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n-1)))
+		windowPower += w * w
+		x[i] = complex(v*w, 0)
+	}
+	FFT(x)
+
+	bins := size/2 + 1
+	freqs := make([]float64, bins)
+	psd := make([]float64, bins)
+	for k := 0; k < bins; k++ {
+		freqs[k] = float64(k) * sampleRate / float64(size)
+		power := real(x[k])*real(x[k]) + imag(x[k])*imag(x[k])
+		psd[k] = power / (sampleRate * windowPower)
+		if k > 0 && k < size/2 {
+			psd[k] *= 2
+		}
+	}
+	return freqs, psd
+}
+
+// Peaks returns the indices of the n strongest local maxima of psd,
+// strongest first. The DC bin is never a peak.
+func Peaks(psd []float64, n int) []int {
+	var peaks []int
+	for k := 1; k < len(psd); k++ {
+		if psd[k] <= psd[k-1] {
+			continue
+		}
+		if k+1 < len(psd) && psd[k] < psd[k+1] {
+			continue
+		}
+		peaks = append(peaks, k)
+	}
+	sort.Slice(peaks, func(i, j int) bool { return psd[peaks[i]] > psd[peaks[j]] })
+	if len(peaks) > n {
+		peaks = peaks[:n]
+	}
+	return peaks
+}
+
+// detrend removes the least-squares line from values.
+func detrend(values []float64) []float64 {
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x := float64(i)
+		sumX += x
+		sumY += v
+		sumXY += x * v
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = v - (intercept + slope*float64(i))
+	}
+	return result
+}
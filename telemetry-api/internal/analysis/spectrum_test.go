@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	const n = 64
+
+	tests := []struct {
+		name string
+		x    func(i int) float64
+		want map[int]complex128 // nonzero bins; every other bin must be zero
+	}{
+		{name: "constant", x: func(int) float64 { return 2 }, want: map[int]complex128{0: 2 * n}},
+		{name: "cosine", x: func(i int) float64 { return math.Cos(2 * math.Pi * 5 * float64(i) / n) }, want: map[int]complex128{5: n / 2, n - 5: n / 2}},
+		{name: "sine", x: func(i int) float64 { return 3 * math.Sin(2*math.Pi*8*float64(i)/n) }, want: map[int]complex128{8: complex(0, -3*n/2), n - 8: complex(0, 3*n/2)}},
+		{name: "impulse", x: func(i int) float64 {
+			if i == 0 {
+				return 1
+			}
+			return 0
+		}, want: func() map[int]complex128 {
+			flat := make(map[int]complex128, n)
+			for k := 0; k < n; k++ {
+				flat[k] = 1
+			}
+			return flat
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := make([]complex128, n)
+			for i := range x {
+				x[i] = complex(tt.x(i), 0)
+			}
+			FFT(x)
+			for k, got := range x {
+				if want := tt.want[k]; cmplx.Abs(got-want) > 1e-9 {
+					t.Errorf("bin %d = %v, want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFFTMatchesDFT(t *testing.T) {
+	const n = 32
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Sin(float64(i)*0.7)+0.3*float64(i%5), math.Cos(float64(i)*1.3))
+	}
+
+	want := make([]complex128, n)
+	for k := range want {
+		for i, v := range x {
+			want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*i)/n))
+		}
+	}
+
+	FFT(x)
+	for k := range x {
+		if cmplx.Abs(x[k]-want[k]) > 1e-9 {
+			t.Errorf("bin %d = %v, want %v", k, x[k], want[k])
+		}
+	}
+}
+
+func TestPeriodogram(t *testing.T) {
+	// A 0.1 Hz sinusoid on a linear trend, sampled at 2 Hz. 300 samples pad
+	// to 512, so bins are 2/512 Hz apart.
+	const sampleRate = 2.0
+	values := make([]float64, 300)
+	for i := range values {
+		elapsed := float64(i) / sampleRate
+		values[i] = 5*math.Sin(2*math.Pi*0.1*elapsed) + 0.5*elapsed + 20
+	}
+
+	freqs, psd := Periodogram(values, sampleRate)
+	if len(freqs) != 257 || len(psd) != 257 {
+		t.Fatalf("got %d bins, want 257", len(freqs))
+	}
+	if freqs[256] != sampleRate/2 {
+		t.Errorf("last bin at %g Hz, want the Nyquist frequency %g", freqs[256], sampleRate/2)
+	}
+
+	peaks := Peaks(psd, 1)
+	if len(peaks) != 1 {
+		t.Fatalf("got %d peaks, want 1", len(peaks))
+	}
+	if got := freqs[peaks[0]]; math.Abs(got-0.1) > sampleRate/512 {
+		t.Errorf("peak at %g Hz, want 0.1 Hz", got)
+	}
+	// Detrending removes the offset and the ramp.
+	if psd[0] > psd[peaks[0]]*1e-3 {
+		t.Errorf("DC bin %g not removed (peak %g)", psd[0], psd[peaks[0]])
+	}
+
+	if freqs, psd := Periodogram([]float64{1}, sampleRate); freqs != nil || psd != nil {
+		t.Error("expected no spectrum for a single value")
+	}
+}
+
+func TestPeaks(t *testing.T) {
+	psd := []float64{9, 1, 4, 2, 2, 6, 1, 3}
+	got := Peaks(psd, 2)
+	if len(got) != 2 || got[0] != 5 || got[1] != 2 {
+		t.Errorf("got %v, want [5 2]", got)
+	}
+	if got := Peaks(psd, 10); len(got) != 3 {
+		t.Errorf("got %v, want 3 peaks", got)
+	}
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Trend is a fitted line y = Intercept + Slope*x with a confidence interval
+// on the slope. SlopeLow and SlopeHigh each come with their own intercept so
+// that the bounding lines pass through the bulk of the data.
+type Trend struct {
+	Slope         float64
+	Intercept     float64
+	SlopeLow      float64
+	InterceptLow  float64
+	SlopeHigh     float64
+	InterceptHigh float64
+}
+
+// At evaluates the fitted line.
+func (t Trend) At(x float64) float64 {
+	return t.Intercept + t.Slope*x
+}
+
+// TheilSen fits a robust line through the points where y is present: the
+// slope is the median of all pairwise slopes and the intercept the median
+// residual. The slope interval uses Sen's method at the normal quantile z
+// (1.96 for 95%). ok is false for fewer than three points.
+func TheilSen(x, y []float64, z float64) (Trend, bool) {
+	var px, py []float64
+	for i := range x {
+		if !math.IsNaN(y[i]) {
+			px = append(px, x[i])
+			py = append(py, y[i])
+		}
+	}
+	n := len(px)
+	if n < 3 {
+		return Trend{}, false
+	}
+
+	slopes := make([]float64, 0, n*(n-1)/2)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if px[j] != px[i] {
+				slopes = append(slopes, (py[j]-py[i])/(px[j]-px[i]))
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return Trend{}, false
+	}
+	sort.Float64s(slopes)
+
+	// Ranks of the interval bounds among the sorted slopes, from the
+	// variance of Kendall's S without tie correction.
+	c := z * math.Sqrt(float64(n*(n-1)*(2*n+5))/18)
+	m := float64(len(slopes))
+	low := int(math.Floor((m - c) / 2))
+	high := int(math.Ceil((m + c) / 2))
+	if low < 0 {
+		low = 0
+	}
+	if high > len(slopes)-1 {
+		high = len(slopes) - 1
+	}
+
+	trend := Trend{
+		Slope:     median(slopes),
+		SlopeLow:  slopes[low],
+		SlopeHigh: slopes[high],
+	}
+	trend.Intercept = intercept(px, py, trend.Slope)
+	trend.InterceptLow = intercept(px, py, trend.SlopeLow)
+	trend.InterceptHigh = intercept(px, py, trend.SlopeHigh)
+	return trend, true
+}
+
+func intercept(x, y []float64, slope float64) float64 {
+	residuals := make([]float64, len(x))
+	for i := range x {
+		residuals[i] = y[i] - slope*x[i]
+	}
+	sort.Float64s(residuals)
+	return median(residuals)
+}
+
+// median of sorted values.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
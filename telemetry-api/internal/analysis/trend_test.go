@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTheilSen(t *testing.T) {
+	noisyLine := func(n int, slope, intercept, noise float64) ([]float64, []float64) {
+		r := rand.New(rand.NewSource(1))
+		x := make([]float64, n)
+		y := make([]float64, n)
+		for i := range x {
+			x[i] = float64(i)
+			y[i] = intercept + slope*x[i] + noise*(2*r.Float64()-1)
+		}
+		return x, y
+	}
+
+	x, y := noisyLine(100, 0.5, 10, 1)
+	outX, outY := noisyLine(100, -2, 40, 0.5)
+	for i := 0; i < 10; i++ {
+		outY[i*10] += 500
+	}
+	gapX, gapY := noisyLine(50, 1, 0, 0.1)
+	for i := 0; i < len(gapY); i += 3 {
+		gapY[i] = math.NaN()
+	}
+
+	tests := []struct {
+		name      string
+		x, y      []float64
+		slope     float64
+		intercept float64
+		tolerance float64
+		ok        bool
+	}{
+		{name: "exact line", x: []float64{0, 1, 2, 3}, y: []float64{1, 3, 5, 7}, slope: 2, intercept: 1, tolerance: 1e-12, ok: true},
+		{name: "noisy line", x: x, y: y, slope: 0.5, intercept: 10, tolerance: 0.05, ok: true},
+		{name: "outliers", x: outX, y: outY, slope: -2, intercept: 40, tolerance: 0.05, ok: true},
+		{name: "missing values skipped", x: gapX, y: gapY, slope: 1, intercept: 0, tolerance: 0.05, ok: true},
+		{name: "too few points", x: []float64{0, 1}, y: []float64{0, 1}},
+		{name: "too few present", x: []float64{0, 1, 2}, y: []float64{0, math.NaN(), 2}},
+		{name: "identical x", x: []float64{1, 1, 1}, y: []float64{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend, ok := TheilSen(tt.x, tt.y, 1.96)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(trend.Slope-tt.slope) > tt.tolerance {
+				t.Errorf("slope = %g, want %g", trend.Slope, tt.slope)
+			}
+			if math.Abs(trend.Intercept-tt.intercept) > tt.tolerance*10 {
+				t.Errorf("intercept = %g, want %g", trend.Intercept, tt.intercept)
+			}
+			if trend.SlopeLow > trend.Slope || trend.SlopeHigh < trend.Slope {
+				t.Errorf("slope interval [%g, %g] does not contain %g", trend.SlopeLow, trend.SlopeHigh, trend.Slope)
+			}
+			if mid := trend.At(50); math.Abs(mid-(tt.intercept+50*tt.slope)) > tt.tolerance*60 {
+				t.Errorf("At(50) = %g, want about %g", mid, tt.intercept+50*tt.slope)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// GetAnomalyCounts counts anomalies in [start, end) by interval bucket,
+// subsystem and type. Only non-empty cells are returned.
+func (d *Database) GetAnomalyCounts(start, end time.Time, interval time.Duration, subsystemIDs []uint16) ([]models.AnomalyCount, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	sqlQuery := `
+		SELECT time_bucket($1::interval, timestamp) AS bucket_start, subsystem_id, anomaly_type::text, COUNT(*)
+		FROM anomalies
+		WHERE timestamp >= $2 AND timestamp < $3`
+
+	args := []interface{}{fmt.Sprintf("%d seconds", int64(interval.Seconds())), start, end}
+	if len(subsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($4)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	sqlQuery += " GROUP BY bucket_start, subsystem_id, anomaly_type ORDER BY bucket_start"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_anomaly_counts", time.Since(queryStart), err)
+		return nil, fmt.Errorf("error counting anomalies: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []models.AnomalyCount
+	for rows.Next() {
+		var count models.AnomalyCount
+		if err := rows.Scan(&count.Bucket, &count.SubsystemID, &count.AnomalyType, &count.Count); err != nil {
+			observability.RecordDBQuery(ctx, "get_anomaly_counts_scan", time.Since(queryStart), err)
+			return nil, fmt.Errorf("error scanning anomaly count: %v", err)
+		}
+		counts = append(counts, count)
+	}
+
+	observability.RecordDBQuery(ctx, "get_anomaly_counts", time.Since(queryStart), nil)
+
+	return counts, nil
+}
+
+// GetAnomalyPeriod totals the anomalies in [start, end) by type and measures
+// the mean time between them.
+func (d *Database) GetAnomalyPeriod(start, end time.Time, subsystemIDs []uint16) (models.AnomalyPeriod, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	sqlQuery := `
+		SELECT anomaly_type::text, COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM anomalies
+		WHERE timestamp >= $1 AND timestamp < $2`
+
+	args := []interface{}{start, end}
+	if len(subsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($3)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	sqlQuery += " GROUP BY anomaly_type"
+
+	period := models.AnomalyPeriod{Start: start, End: end, ByType: map[string]int{}}
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_anomaly_period", time.Since(queryStart), err)
+		return period, fmt.Errorf("error totalling anomalies: %v", err)
+	}
+	defer rows.Close()
+
+	var first, last time.Time
+	for rows.Next() {
+		var anomalyType string
+		var count int
+		var typeFirst, typeLast time.Time
+		if err := rows.Scan(&anomalyType, &count, &typeFirst, &typeLast); err != nil {
+			observability.RecordDBQuery(ctx, "get_anomaly_period_scan", time.Since(queryStart), err)
+			return period, fmt.Errorf("error scanning anomaly total: %v", err)
+		}
+		period.ByType[anomalyType] = count
+		period.Total += count
+		if first.IsZero() || typeFirst.Before(first) {
+			first = typeFirst
+		}
+		if typeLast.After(last) {
+			last = typeLast
+		}
+	}
+
+	observability.RecordDBQuery(ctx, "get_anomaly_period", time.Since(queryStart), nil)
+
+	if period.Total > 1 {
+		mean := last.Sub(first).Seconds() / float64(period.Total-1)
+		period.MeanTimeBetweenSeconds = &mean
+	}
+	return period, nil
+}
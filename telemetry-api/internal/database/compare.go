@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// GetRelativeBuckets averages each parameter over buckets of interval counted
+// from query.StartTime, so that windows starting at different times line up
+// bucket for bucket. The window is selected with the same filter as
+// GetTelemetry. Parameters must already be validated against
+// models.Parameters.
+func (d *Database) GetRelativeBuckets(query *models.TelemetryQuery, interval time.Duration, parameters []string) ([]models.RelativeBucket, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	from, args := telemetryFilter(query)
+
+	var columns []string
+	for _, parameter := range parameters {
+		columns = append(columns, aggregateExpr("avg", parameter))
+	}
+
+	args = append(args, interval.Seconds())
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			subsystem_id,
+			FLOOR(EXTRACT(EPOCH FROM timestamp - $1)::float8 / $%d::float8)::int AS bucket_index,
+			%s
+		FROM %s
+		GROUP BY subsystem_id, bucket_index
+		ORDER BY subsystem_id, bucket_index`, len(args), strings.Join(columns, ",\n\t\t\t"), from)
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_relative_buckets", time.Since(start), err)
+		return nil, fmt.Errorf("error querying relative buckets: %v", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.RelativeBucket
+	for rows.Next() {
+		var bucket models.RelativeBucket
+		values := make([]sql.NullFloat64, len(columns))
+
+		dest := []interface{}{&bucket.SubsystemID, &bucket.Index}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			observability.RecordDBQuery(ctx, "get_relative_buckets_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning relative bucket: %v", err)
+		}
+
+		bucket.Values = make(map[string]float64, len(parameters))
+		for i, parameter := range parameters {
+			if values[i].Valid {
+				bucket.Values[parameter] = values[i].Float64
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	observability.RecordDBQuery(ctx, "get_relative_buckets", time.Since(start), nil)
+
+	return buckets, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+
+	"github.com/lib/pq"
+)
+
+// GetSubsystemSpans returns the first and last sample of each subsystem in
+// [start, end) and how many samples it has.
+func (d *Database) GetSubsystemSpans(start, end time.Time, subsystemIDs []uint16) ([]models.SubsystemSpan, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	sqlQuery := `
+		SELECT subsystem_id, MIN(timestamp), MAX(timestamp), COUNT(*)
+		FROM telemetry
+		WHERE timestamp >= $1 AND timestamp < $2`
+
+	args := []interface{}{start, end}
+	if len(subsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($3)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	sqlQuery += " GROUP BY subsystem_id ORDER BY subsystem_id"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_subsystem_spans", time.Since(queryStart), err)
+		return nil, fmt.Errorf("error querying subsystem spans: %v", err)
+	}
+	defer rows.Close()
+
+	var spans []models.SubsystemSpan
+	for rows.Next() {
+		var span models.SubsystemSpan
+		if err := rows.Scan(&span.SubsystemID, &span.First, &span.Last, &span.Samples); err != nil {
+			observability.RecordDBQuery(ctx, "get_subsystem_spans_scan", time.Since(queryStart), err)
+			return nil, fmt.Errorf("error scanning subsystem span: %v", err)
+		}
+		spans = append(spans, span)
+	}
+
+	observability.RecordDBQuery(ctx, "get_subsystem_spans", time.Since(queryStart), nil)
+
+	return spans, nil
+}
+
+// GetGaps returns every gap between consecutive samples of a subsystem in
+// [start, end) longer than its threshold: thresholds[id], or
+// defaultThreshold for subsystems not listed. Each gap is classified by
+// whether any telemetry generated during it was received during it.
+func (d *Database) GetGaps(start, end time.Time, subsystemIDs []uint16, defaultThreshold time.Duration, thresholds map[uint16]time.Duration) ([]models.GapRow, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	var ids pq.Int64Array
+	var seconds pq.Float64Array
+	for id, threshold := range thresholds {
+		ids = append(ids, int64(id))
+		seconds = append(seconds, threshold.Seconds())
+	}
+
+	args := []interface{}{start, end, ids, seconds, defaultThreshold.Seconds()}
+	subsystemFilter := ""
+	if len(subsystemIDs) > 0 {
+		args = append(args, subsystemArray(subsystemIDs))
+		subsystemFilter = " AND subsystem_id = ANY($6)"
+	}
+
+	sqlQuery := `
+		WITH samples AS (
+			SELECT subsystem_id, timestamp, created_at,
+				LAG(timestamp) OVER w AS prev_timestamp,
+				LAG(created_at) OVER w AS prev_created_at
+			FROM telemetry
+			WHERE timestamp >= $1 AND timestamp < $2` + subsystemFilter + `
+			WINDOW w AS (PARTITION BY subsystem_id ORDER BY timestamp)
+		), gaps AS (
+			SELECT s.*
+			FROM samples s
+			LEFT JOIN unnest($3::int[], $4::float8[]) AS c(subsystem_id, seconds) USING (subsystem_id)
+			WHERE s.timestamp - s.prev_timestamp > make_interval(secs => COALESCE(c.seconds, $5))
+		)
+		SELECT g.subsystem_id, g.prev_timestamp, g.timestamp,
+			EXISTS (
+				SELECT 1 FROM telemetry o
+				WHERE o.timestamp > g.prev_timestamp AND o.timestamp < g.timestamp
+					AND o.created_at > g.prev_created_at AND o.created_at < g.created_at
+			) AS link_up
+		FROM gaps g
+		ORDER BY g.subsystem_id, g.prev_timestamp`
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_gaps", time.Since(queryStart), err)
+		return nil, fmt.Errorf("error querying gaps: %v", err)
+	}
+	defer rows.Close()
+
+	var gaps []models.GapRow
+	for rows.Next() {
+		var gap models.GapRow
+		var linkUp bool
+		if err := rows.Scan(&gap.SubsystemID, &gap.Start, &gap.End, &linkUp); err != nil {
+			observability.RecordDBQuery(ctx, "get_gaps_scan", time.Since(queryStart), err)
+			return nil, fmt.Errorf("error scanning gap: %v", err)
+		}
+		gap.Cause = models.GapGroundLink
+		if linkUp {
+			gap.Cause = models.GapOnboard
+		}
+		gaps = append(gaps, gap)
+	}
+
+	observability.RecordDBQuery(ctx, "get_gaps", time.Since(queryStart), nil)
+
+	return gaps, nil
+}
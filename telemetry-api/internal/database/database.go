@@ -4,21 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"telemetry-api/internal/models"
 	"time"
 
 	"telemetry-api/internal/observability"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Database struct {
 	db *sql.DB
 }
 
-func NewDatabase(host string, port int, user, password, dbname string) (*Database, error) {
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+func ConnString(host string, port int, user, password, dbname string) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
+}
+
+func NewDatabase(host string, port int, user, password, dbname string) (*Database, error) {
+	connStr := ConnString(host, port, user, password, dbname)
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -32,35 +37,37 @@ func NewDatabase(host string, port int, user, password, dbname string) (*Databas
 	return &Database{db: db}, nil
 }
 
-func (d *Database) GetTelemetry(query *models.TelemetryQuery) ([]models.TelemetryRecord, int, error) {
-	fmt.Println(query)
+// GetTelemetry returns one page of records, newest first. Pages are addressed
+// by query.After (keyset) when set, otherwise by page number.
+func (d *Database) GetTelemetry(query *models.TelemetryQuery) ([]models.TelemetryRecord, models.PageInfo, error) {
+	ctx := context.Background()
+	start := time.Now()
 
-	baseQuery := `
-		WITH results AS (
-			SELECT timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly,
-				   COUNT(*) OVER() as total_count
-			FROM telemetry
-			WHERE timestamp BETWEEN $1 AND $2 AND subsystem_id = $3`
+	from, args := telemetryFilter(query)
 
-	offset := (query.Page - 1) * query.PageSize
-	baseQuery += " ORDER BY timestamp DESC LIMIT $4 OFFSET $5"
+	total, estimated, err := d.countRows(ctx, query.Total, from, args)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "count_telemetry", time.Since(start), err)
+		return nil, models.PageInfo{}, fmt.Errorf("error counting telemetry: %v", err)
+	}
 
-	baseQuery += ")"
-	baseQuery += `
-		SELECT timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly, total_count
-		FROM results`
+	page, pageArgs := pageClause(query, args)
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+		FROM ` + from + page
 
-	rows, err := d.db.Query(baseQuery, query.StartTime, query.EndTime, query.SubsystemID, query.PageSize, offset)
+	rows, err := d.db.QueryContext(ctx, sqlQuery, pageArgs...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error querying telemetry: %v", err)
+		observability.RecordDBQuery(ctx, "get_telemetry", time.Since(start), err)
+		return nil, models.PageInfo{}, fmt.Errorf("error querying telemetry: %v", err)
 	}
 	defer rows.Close()
 
 	var records []models.TelemetryRecord
-	var totalCount int
 	for rows.Next() {
 		var record models.TelemetryRecord
 		err := rows.Scan(
+			&record.ID,
 			&record.Timestamp,
 			&record.SubsystemID,
 			&record.Temperature,
@@ -68,150 +75,528 @@ func (d *Database) GetTelemetry(query *models.TelemetryQuery) ([]models.Telemetr
 			&record.Altitude,
 			&record.Signal,
 			&record.HasAnomaly,
-			&totalCount,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning telemetry record: %v", err)
+			observability.RecordDBQuery(ctx, "get_telemetry_scan", time.Since(start), err)
+			return nil, models.PageInfo{}, fmt.Errorf("error scanning telemetry record: %v", err)
 		}
 		records = append(records, record)
 	}
 
-	return records, totalCount, nil
+	observability.RecordDBQuery(ctx, "get_telemetry", time.Since(start), nil)
+
+	n, info := finishPage(query, total, estimated, len(records), func(i int) models.Cursor {
+		return models.Cursor{Timestamp: records[i].Timestamp, ID: records[i].ID}
+	})
+	return records[:n], info, nil
 }
 
-func (d *Database) GetCurrentTelemetry() (*models.TelemetryRecord, error) {
+// GetCurrentTelemetry returns the latest record of each subsystem. DISTINCT ON
+// over the (subsystem_id, timestamp DESC) index lets TimescaleDB use a skip
+// scan rather than reading every row.
+func (d *Database) GetCurrentTelemetry(subsystemIDs []uint16) ([]models.TelemetryRecord, error) {
 	ctx := context.Background()
 	start := time.Now()
 
 	query := `
-		SELECT timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
-		FROM telemetry
-		ORDER BY timestamp DESC
-		LIMIT 1`
-
-	var record models.TelemetryRecord
-	err := d.db.QueryRowContext(ctx, query).Scan(
-		&record.Timestamp,
-		&record.SubsystemID,
-		&record.Temperature,
-		&record.Battery,
-		&record.Altitude,
-		&record.Signal,
-		&record.HasAnomaly,
-	)
-
-	observability.RecordDBQuery(ctx, "get_current_telemetry", time.Since(start), err)
+		SELECT DISTINCT ON (subsystem_id)
+			id, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+		FROM telemetry`
+
+	var args []interface{}
+	if len(subsystemIDs) > 0 {
+		query += " WHERE subsystem_id = ANY($1)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	query += " ORDER BY subsystem_id, timestamp DESC"
 
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
+		observability.RecordDBQuery(ctx, "get_current_telemetry", time.Since(start), err)
 		return nil, fmt.Errorf("error getting current telemetry: %v", err)
 	}
+	defer rows.Close()
 
-	return &record, nil
+	var records []models.TelemetryRecord
+	for rows.Next() {
+		var record models.TelemetryRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.Temperature,
+			&record.Battery,
+			&record.Altitude,
+			&record.Signal,
+			&record.HasAnomaly,
+		)
+		if err != nil {
+			observability.RecordDBQuery(ctx, "get_current_telemetry_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning telemetry record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_current_telemetry", time.Since(start), nil)
+
+	return records, nil
 }
 
-func (d *Database) GetAnomalies(query *models.TelemetryQuery) ([]models.AnomalyRecord, int, error) {
+// GetTelemetryAt returns the last record of each subsystem at or before at,
+// skipping records older than maxAge when it is non-zero. Each subsystem is a
+// single backward probe of the (subsystem_id, timestamp DESC, id DESC) index,
+// so neither step scans the telemetry hypertable.
+func (d *Database) GetTelemetryAt(at time.Time, subsystemIDs []uint16, maxAge time.Duration) ([]models.TelemetryRecord, error) {
 	ctx := context.Background()
 	start := time.Now()
 
-	baseQuery := `
-		WITH results AS (
-			SELECT timestamp, subsystem_id, anomaly_type, value, expected_range,
-				   COUNT(*) OVER() as total_count
-			FROM anomalies
-			WHERE timestamp BETWEEN $1 AND $2`
+	args := []interface{}{at}
+	lowerBound, bucketBound := "", ""
+	if maxAge > 0 {
+		args = append(args, at.Add(-maxAge))
+		lowerBound = fmt.Sprintf(" AND t.timestamp >= $%d", len(args))
+		bucketBound = fmt.Sprintf(" AND bucket >= time_bucket('1 day', $%d::timestamptz)", len(args))
+	}
 
-	args := []interface{}{query.StartTime, query.EndTime}
-	if query.SubsystemID != nil {
-		baseQuery += " AND subsystem_id = $3"
-		args = append(args, *query.SubsystemID)
+	// Without a filter, the subsystems come from the daily aggregate, which
+	// holds one row per subsystem and day rather than every sample.
+	subsystems := `SELECT DISTINCT subsystem_id FROM telemetry_1d WHERE bucket <= $1` + bucketBound
+	if len(subsystemIDs) > 0 {
+		args = append(args, subsystemArray(subsystemIDs))
+		subsystems = fmt.Sprintf(`SELECT unnest($%d::smallint[]) AS subsystem_id`, len(args))
 	}
 
-	baseQuery += " ORDER BY timestamp DESC LIMIT $4 OFFSET $5"
-	offset := (query.Page - 1) * query.PageSize
-	args = append(args, query.PageSize, offset)
+	query := `
+		SELECT latest.id, latest.timestamp, latest.subsystem_id, latest.temperature,
+			latest.battery, latest.altitude, latest.signal, latest.has_anomaly
+		FROM (` + subsystems + `) s
+		CROSS JOIN LATERAL (
+			SELECT id, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+			FROM telemetry t
+			WHERE t.subsystem_id = s.subsystem_id AND t.timestamp <= $1` + lowerBound + `
+			ORDER BY t.timestamp DESC, t.id DESC
+			LIMIT 1
+		) latest
+		ORDER BY latest.subsystem_id`
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_telemetry_at", time.Since(start), err)
+		return nil, fmt.Errorf("error getting telemetry snapshot: %v", err)
+	}
+	defer rows.Close()
 
-	baseQuery += ")"
-	baseQuery += `
-		SELECT timestamp, subsystem_id, anomaly_type, value, expected_range, total_count
-		FROM results`
+	var records []models.TelemetryRecord
+	for rows.Next() {
+		var record models.TelemetryRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.Temperature,
+			&record.Battery,
+			&record.Altitude,
+			&record.Signal,
+			&record.HasAnomaly,
+		)
+		if err != nil {
+			observability.RecordDBQuery(ctx, "get_telemetry_at_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning telemetry record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_telemetry_at", time.Since(start), nil)
 
-	rows, err := d.db.QueryContext(ctx, baseQuery, args...)
+	return records, nil
+}
+
+// GetSubsystems lists every subsystem that has reported telemetry.
+func (d *Database) GetSubsystems() ([]models.Subsystem, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	query := `
+		SELECT DISTINCT ON (subsystem_id) subsystem_id, timestamp
+		FROM telemetry
+		ORDER BY subsystem_id, timestamp DESC`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_subsystems", time.Since(start), err)
+		return nil, fmt.Errorf("error querying subsystems: %v", err)
+	}
+	defer rows.Close()
+
+	var subsystems []models.Subsystem
+	for rows.Next() {
+		var subsystem models.Subsystem
+		if err := rows.Scan(&subsystem.ID, &subsystem.LastSeen); err != nil {
+			observability.RecordDBQuery(ctx, "get_subsystems_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning subsystem: %v", err)
+		}
+		subsystems = append(subsystems, subsystem)
+	}
+
+	observability.RecordDBQuery(ctx, "get_subsystems", time.Since(start), nil)
+
+	return subsystems, nil
+}
+
+// GetAnomalies returns one page of anomalies, newest first, paged the same
+// way as GetTelemetry.
+func (d *Database) GetAnomalies(query *models.TelemetryQuery) ([]models.AnomalyRecord, models.PageInfo, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	from, args := anomalyFilter(query)
+
+	total, estimated, err := d.countRows(ctx, query.Total, from, args)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "count_anomalies", time.Since(start), err)
+		return nil, models.PageInfo{}, fmt.Errorf("error counting anomalies: %v", err)
+	}
+
+	page, pageArgs := pageClause(query, args)
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, anomaly_type, value, expected_range
+		FROM ` + from + page
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, pageArgs...)
 	if err != nil {
 		observability.RecordDBQuery(ctx, "get_anomalies", time.Since(start), err)
-		return nil, 0, fmt.Errorf("error querying anomalies: %v", err)
+		return nil, models.PageInfo{}, fmt.Errorf("error querying anomalies: %v", err)
 	}
 	defer rows.Close()
 
 	var records []models.AnomalyRecord
-	var totalCount int
 	for rows.Next() {
 		var record models.AnomalyRecord
 		err := rows.Scan(
+			&record.ID,
 			&record.Timestamp,
 			&record.SubsystemID,
 			&record.AnomalyType,
 			&record.Value,
 			&record.ExpectedRange,
-			&totalCount,
 		)
 		if err != nil {
 			observability.RecordDBQuery(ctx, "get_anomalies_scan", time.Since(start), err)
-			return nil, 0, fmt.Errorf("error scanning anomaly record: %v", err)
+			return nil, models.PageInfo{}, fmt.Errorf("error scanning anomaly record: %v", err)
 		}
 		records = append(records, record)
 	}
 
 	observability.RecordDBQuery(ctx, "get_anomalies", time.Since(start), nil)
 
-	return records, totalCount, nil
+	n, info := finishPage(query, total, estimated, len(records), func(i int) models.Cursor {
+		return models.Cursor{Timestamp: records[i].Timestamp, ID: records[i].ID}
+	})
+	return records[:n], info, nil
 }
 
-func (d *Database) GetAggregatedTelemetry(query *models.TelemetryAggregationQuery) ([]models.AggregatedMetric, error) {
-	timeInterval := fmt.Sprintf("time_bucket('%s', timestamp)", query.GroupBy)
+// GetTelemetryAfter returns records stored after the cursor, in (created_at,
+// id) order, so stream clients can resume from their last event. A non-zero
+// since also bounds the spacecraft timestamp.
+func (d *Database) GetTelemetryAfter(after models.Cursor, since time.Time, subsystemIDs []uint16, limit int) ([]models.TelemetryRecord, error) {
+	ctx := context.Background()
+	start := time.Now()
 
-	sqlQuery := fmt.Sprintf(`
-		SELECT 
-			%s as timestamp,
-			subsystem_id,
-			MIN(temperature) as min,
-			MAX(temperature) as max,
-			AVG(temperature) as avg,
-			COUNT(*) as count
+	sqlQuery := `
+		SELECT id, created_at, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
 		FROM telemetry
-		WHERE timestamp BETWEEN $1 AND $2`, timeInterval)
+		WHERE (created_at, id) > ($1, $2)`
 
-	args := []interface{}{query.StartTime, query.EndTime}
-	if query.SubsystemID != nil {
-		sqlQuery += " AND subsystem_id = $3"
-		args = append(args, *query.SubsystemID)
+	args := []interface{}{after.Timestamp, after.ID}
+	if !since.IsZero() {
+		args = append(args, since)
+		sqlQuery += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if len(subsystemIDs) > 0 {
+		args = append(args, subsystemArray(subsystemIDs))
+		sqlQuery += fmt.Sprintf(" AND subsystem_id = ANY($%d)", len(args))
 	}
 
-	sqlQuery += fmt.Sprintf(" GROUP BY %s, subsystem_id ORDER BY timestamp DESC", timeInterval)
+	args = append(args, limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
 
-	rows, err := d.db.Query(sqlQuery, args...)
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error querying aggregated telemetry: %v", err)
+		observability.RecordDBQuery(ctx, "get_telemetry_after", time.Since(start), err)
+		return nil, fmt.Errorf("error querying telemetry: %v", err)
 	}
 	defer rows.Close()
 
-	var metrics []models.AggregatedMetric
+	var records []models.TelemetryRecord
 	for rows.Next() {
-		var metric models.AggregatedMetric
+		var record models.TelemetryRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.CreatedAt,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.Temperature,
+			&record.Battery,
+			&record.Altitude,
+			&record.Signal,
+			&record.HasAnomaly,
+		)
+		if err != nil {
+			observability.RecordDBQuery(ctx, "get_telemetry_after_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning telemetry record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_telemetry_after", time.Since(start), nil)
+
+	return records, nil
+}
+
+// GetAnomaliesAfter is the anomaly counterpart of GetTelemetryAfter.
+func (d *Database) GetAnomaliesAfter(after models.Cursor, since time.Time, subsystemIDs []uint16, limit int) ([]models.AnomalyRecord, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	sqlQuery := `
+		SELECT id, created_at, timestamp, subsystem_id, anomaly_type, value, expected_range
+		FROM anomalies
+		WHERE (created_at, id) > ($1, $2)`
+
+	args := []interface{}{after.Timestamp, after.ID}
+	if !since.IsZero() {
+		args = append(args, since)
+		sqlQuery += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if len(subsystemIDs) > 0 {
+		args = append(args, subsystemArray(subsystemIDs))
+		sqlQuery += fmt.Sprintf(" AND subsystem_id = ANY($%d)", len(args))
+	}
+
+	args = append(args, limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_anomalies_after", time.Since(start), err)
+		return nil, fmt.Errorf("error querying anomalies: %v", err)
+	}
+	defer rows.Close()
+
+	var records []models.AnomalyRecord
+	for rows.Next() {
+		var record models.AnomalyRecord
 		err := rows.Scan(
-			&metric.Timestamp,
-			&metric.SubsystemID,
-			&metric.Min,
-			&metric.Max,
-			&metric.Avg,
-			&metric.Count,
+			&record.ID,
+			&record.CreatedAt,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.AnomalyType,
+			&record.Value,
+			&record.ExpectedRange,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning aggregated metric: %v", err)
+			observability.RecordDBQuery(ctx, "get_anomalies_after_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning anomaly record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_anomalies_after", time.Since(start), nil)
+
+	return records, nil
+}
+
+// GetTelemetryBetween returns every record in [start, end) in timestamp order.
+func (d *Database) GetTelemetryBetween(start, end time.Time, subsystemIDs []uint16) ([]models.TelemetryRecord, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+		FROM telemetry
+		WHERE timestamp >= $1 AND timestamp < $2`
+
+	args := []interface{}{start, end}
+	if len(subsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($3)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	sqlQuery += " ORDER BY timestamp, id"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_telemetry_between", time.Since(queryStart), err)
+		return nil, fmt.Errorf("error querying telemetry: %v", err)
+	}
+	defer rows.Close()
+
+	var records []models.TelemetryRecord
+	for rows.Next() {
+		var record models.TelemetryRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.Temperature,
+			&record.Battery,
+			&record.Altitude,
+			&record.Signal,
+			&record.HasAnomaly,
+		)
+		if err != nil {
+			observability.RecordDBQuery(ctx, "get_telemetry_between_scan", time.Since(queryStart), err)
+			return nil, fmt.Errorf("error scanning telemetry record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_telemetry_between", time.Since(queryStart), nil)
+
+	return records, nil
+}
+
+// GetAnomaliesBetween returns every anomaly in [start, end) in timestamp order.
+func (d *Database) GetAnomaliesBetween(start, end time.Time, subsystemIDs []uint16) ([]models.AnomalyRecord, error) {
+	ctx := context.Background()
+	queryStart := time.Now()
+
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, anomaly_type, value, expected_range
+		FROM anomalies
+		WHERE timestamp >= $1 AND timestamp < $2`
+
+	args := []interface{}{start, end}
+	if len(subsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($3)"
+		args = append(args, subsystemArray(subsystemIDs))
+	}
+	sqlQuery += " ORDER BY timestamp, id"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_anomalies_between", time.Since(queryStart), err)
+		return nil, fmt.Errorf("error querying anomalies: %v", err)
+	}
+	defer rows.Close()
+
+	var records []models.AnomalyRecord
+	for rows.Next() {
+		var record models.AnomalyRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.AnomalyType,
+			&record.Value,
+			&record.ExpectedRange,
+		)
+		if err != nil {
+			observability.RecordDBQuery(ctx, "get_anomalies_between_scan", time.Since(queryStart), err)
+			return nil, fmt.Errorf("error scanning anomaly record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	observability.RecordDBQuery(ctx, "get_anomalies_between", time.Since(queryStart), nil)
+
+	return records, nil
+}
+
+// GetAggregatedTelemetry buckets telemetry by the query's group_by interval and
+// applies each function to each parameter, reading from the coarsest
+// continuous aggregate that can serve the interval. It returns the metrics
+// and the resolution used. Parameters, functions and the interval must
+// already be validated against the models whitelists, since column names and
+// function calls cannot be bound as query parameters.
+func (d *Database) GetAggregatedTelemetry(query *models.TelemetryAggregationQuery, parameters, functions []string) ([]models.AggregatedMetric, string, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	interval := models.AggregationIntervals[query.GroupBy]
+
+	table, timeColumn, countExpr, expr := "telemetry", "timestamp", "COUNT(*)", aggregateExpr
+	where := "timestamp BETWEEN $2 AND $3"
+	resolutionName := RawResolution
+	if res := selectResolution(interval, functions); res != nil {
+		// Rollup buckets that straddle either edge are counted whole, so the
+		// first and last groups may include samples up to one rollup width
+		// outside the range.
+		table, timeColumn, countExpr, expr = res.table, "bucket", "SUM(sample_count)::bigint", rollupExpr
+		where = res.startBound("$2") + " AND bucket <= $3"
+		resolutionName = res.name
+	}
+
+	var columns []string
+	for _, parameter := range parameters {
+		for _, function := range functions {
+			columns = append(columns, expr(function, parameter))
+		}
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			time_bucket($1::interval, %[1]s) as bucket_start,
+			subsystem_id,
+			%[2]s as count,
+			%[3]s
+		FROM %[4]s
+		WHERE %[5]s`, timeColumn, countExpr, strings.Join(columns, ",\n\t\t\t"), table, where)
+
+	args := []interface{}{fmt.Sprintf("%d seconds", int64(interval.Seconds())), query.StartTime, query.EndTime}
+	if len(query.SubsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($4)"
+		args = append(args, subsystemArray(query.SubsystemIDs))
+	}
+
+	sqlQuery += " GROUP BY bucket_start, subsystem_id ORDER BY bucket_start DESC"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_aggregated_telemetry", time.Since(start), err)
+		return nil, "", fmt.Errorf("error querying aggregated telemetry: %v", err)
+	}
+	defer rows.Close()
+
+	var metrics []models.AggregatedMetric
+	for rows.Next() {
+		var metric models.AggregatedMetric
+		values := make([]sql.NullFloat64, len(columns))
+
+		dest := []interface{}{&metric.Timestamp, &metric.SubsystemID, &metric.Count}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			observability.RecordDBQuery(ctx, "get_aggregated_telemetry_scan", time.Since(start), err)
+			return nil, "", fmt.Errorf("error scanning aggregated metric: %v", err)
+		}
+
+		metric.Values = make(map[string]map[string]float64, len(parameters))
+		for i, parameter := range parameters {
+			metric.Values[parameter] = make(map[string]float64, len(functions))
+			for j, function := range functions {
+				if v := values[i*len(functions)+j]; v.Valid {
+					metric.Values[parameter][function] = v.Float64
+				}
+			}
 		}
 		metrics = append(metrics, metric)
 	}
 
-	return metrics, nil
+	observability.RecordDBQuery(ctx, "get_aggregated_telemetry", time.Since(start), nil)
+
+	return metrics, resolutionName, nil
+}
+
+// subsystemArray converts subsystem ids for binding to = ANY($n).
+func subsystemArray(ids []uint16) interface{} {
+	values := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		values[i] = int64(id)
+	}
+	return values
 }
 
 func (d *Database) Close() error {
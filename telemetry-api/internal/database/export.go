@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// exportFetchSize is how many rows each FETCH pulls from the server-side
+// cursor, which bounds memory regardless of the size of the export.
+const exportFetchSize = 5000
+
+// StreamTelemetry calls fn for every record matching query, oldest first. Rows
+// are read through a server-side cursor, so only one batch is held at a time.
+// An error from fn stops the export and is returned as is.
+func (d *Database) StreamTelemetry(ctx context.Context, query *models.TelemetryQuery, fn func(models.TelemetryRecord) error) error {
+	start := time.Now()
+
+	from, args := telemetryFilter(query)
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+		FROM ` + from + ` ORDER BY timestamp, id`
+
+	err := d.withCursor(ctx, sqlQuery, args, func(rows *sql.Rows) error {
+		var record models.TelemetryRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.Temperature,
+			&record.Battery,
+			&record.Altitude,
+			&record.Signal,
+			&record.HasAnomaly,
+		)
+		if err != nil {
+			return fmt.Errorf("error scanning telemetry record: %v", err)
+		}
+		return fn(record)
+	})
+
+	observability.RecordDBQuery(ctx, "stream_telemetry", time.Since(start), err)
+	return err
+}
+
+// StreamAnomalies is StreamTelemetry for anomalies.
+func (d *Database) StreamAnomalies(ctx context.Context, query *models.TelemetryQuery, fn func(models.AnomalyRecord) error) error {
+	start := time.Now()
+
+	from, args := anomalyFilter(query)
+	sqlQuery := `
+		SELECT id, timestamp, subsystem_id, anomaly_type, value, expected_range
+		FROM ` + from + ` ORDER BY timestamp, id`
+
+	err := d.withCursor(ctx, sqlQuery, args, func(rows *sql.Rows) error {
+		var record models.AnomalyRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.Timestamp,
+			&record.SubsystemID,
+			&record.AnomalyType,
+			&record.Value,
+			&record.ExpectedRange,
+		)
+		if err != nil {
+			return fmt.Errorf("error scanning anomaly record: %v", err)
+		}
+		return fn(record)
+	})
+
+	observability.RecordDBQuery(ctx, "stream_anomalies", time.Since(start), err)
+	return err
+}
+
+// withCursor declares a cursor for sqlQuery in a read-only transaction and
+// calls scan for each row, fetching exportFetchSize rows at a time.
+func (d *Database) withCursor(ctx context.Context, sqlQuery string, args []interface{}, scan func(*sql.Rows) error) error {
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error starting export transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DECLARE export_cursor NO SCROLL CURSOR FOR `+sqlQuery, args...); err != nil {
+		return fmt.Errorf("error declaring export cursor: %v", err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM export_cursor`, exportFetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("error fetching from export cursor: %v", err)
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error reading export cursor: %v", err)
+		}
+
+		if n < exportFetchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+const exportJobColumns = `id, status, request, rows_estimated, rows_written, bytes_written,
+	file_path, error, created_at, started_at, finished_at, expires_at`
+
+// CreateExportJob stores a new pending job.
+func (d *Database) CreateExportJob(id string, request models.ExportRequest) (*models.ExportJob, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding export request: %v", err)
+	}
+
+	row := d.db.QueryRowContext(ctx, `
+		INSERT INTO export_jobs (id, request)
+		VALUES ($1, $2)
+		RETURNING `+exportJobColumns, id, body)
+
+	job, err := scanExportJob(row)
+	observability.RecordDBQuery(ctx, "create_export_job", time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("error creating export job: %v", err)
+	}
+	return job, nil
+}
+
+// GetExportJob returns the job with the given id, or nil if there is none.
+func (d *Database) GetExportJob(id string) (*models.ExportJob, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	row := d.db.QueryRowContext(ctx, `SELECT `+exportJobColumns+` FROM export_jobs WHERE id = $1`, id)
+
+	job, err := scanExportJob(row)
+	if err == sql.ErrNoRows {
+		observability.RecordDBQuery(ctx, "get_export_job", time.Since(start), nil)
+		return nil, nil
+	}
+	observability.RecordDBQuery(ctx, "get_export_job", time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("error querying export job: %v", err)
+	}
+	return job, nil
+}
+
+// ClaimExportJob marks the oldest pending job as running and returns it, or
+// nil if none is waiting. SKIP LOCKED lets several API instances share the
+// queue.
+func (d *Database) ClaimExportJob() (*models.ExportJob, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	row := d.db.QueryRowContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'running', started_at = NOW(), heartbeat_at = NOW(), rows_written = 0, bytes_written = 0
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportJobColumns)
+
+	job, err := scanExportJob(row)
+	if err == sql.ErrNoRows {
+		observability.RecordDBQuery(ctx, "claim_export_job", time.Since(start), nil)
+		return nil, nil
+	}
+	observability.RecordDBQuery(ctx, "claim_export_job", time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming export job: %v", err)
+	}
+	return job, nil
+}
+
+// RequeueExportJobs returns running jobs whose heartbeat is older than
+// timeout to the queue. Their worker has stopped, whether by a restart or a
+// crash, on this instance or another.
+func (d *Database) RequeueExportJobs(timeout time.Duration) (int64, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'pending', started_at = NULL, heartbeat_at = NULL, rows_written = 0, bytes_written = 0
+		WHERE status = 'running'
+			AND COALESCE(heartbeat_at, started_at, created_at) < NOW() - make_interval(secs => $1)`,
+		timeout.Seconds())
+	observability.RecordDBQuery(ctx, "requeue_export_jobs", time.Since(start), err)
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing export jobs: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// UpdateExportProgress records progress on a running job, renewing its
+// heartbeat, and returns its current status, so the worker notices when the
+// job has been cancelled. A job requeued since the worker claimed it at
+// startedAt is reported as pending and left untouched.
+func (d *Database) UpdateExportProgress(id string, startedAt time.Time, rowsEstimated, rowsWritten, bytesWritten int64) (string, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	var status string
+	err := d.db.QueryRowContext(ctx, `
+		UPDATE export_jobs
+		SET rows_estimated = $3, rows_written = $4, bytes_written = $5, heartbeat_at = NOW()
+		WHERE id = $1 AND started_at = $2
+		RETURNING status`, id, startedAt, rowsEstimated, rowsWritten, bytesWritten).Scan(&status)
+	if err == sql.ErrNoRows {
+		observability.RecordDBQuery(ctx, "update_export_progress", time.Since(start), nil)
+		return models.ExportPending, nil
+	}
+	observability.RecordDBQuery(ctx, "update_export_progress", time.Since(start), err)
+	if err != nil {
+		return "", fmt.Errorf("error updating export progress: %v", err)
+	}
+	return status, nil
+}
+
+// FinishExportJob moves a running job to its final status. A job cancelled or
+// requeued in the meantime is left as it is, and false is returned so the
+// caller can discard its file.
+func (d *Database) FinishExportJob(job *models.ExportJob) (bool, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = $2, rows_written = $3, bytes_written = $4, file_path = $5,
+			error = $6, finished_at = NOW(), expires_at = $7
+		WHERE id = $1 AND status = 'running' AND started_at = $8`,
+		job.ID, job.Status, job.RowsWritten, job.BytesWritten, job.FilePath, job.Error, job.ExpiresAt, job.StartedAt)
+	observability.RecordDBQuery(ctx, "finish_export_job", time.Since(start), err)
+	if err != nil {
+		return false, fmt.Errorf("error finishing export job: %v", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CancelExportJob cancels a pending or running job. It reports false if the
+// job had already finished.
+func (d *Database) CancelExportJob(id string) (bool, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'cancelled', finished_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'running')`, id)
+	observability.RecordDBQuery(ctx, "cancel_export_job", time.Since(start), err)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling export job: %v", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ExpireExportJobs marks jobs past their expiry as expired and returns the
+// files they leave behind for the caller to remove.
+func (d *Database) ExpireExportJobs(now time.Time) ([]string, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	rows, err := d.db.QueryContext(ctx, `
+		UPDATE export_jobs expired
+		SET status = 'expired', file_path = ''
+		FROM export_jobs old
+		WHERE expired.id = old.id AND expired.expires_at < $1 AND expired.status <> 'expired'
+		RETURNING old.file_path`, now)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "expire_export_jobs", time.Since(start), err)
+		return nil, fmt.Errorf("error expiring export jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			observability.RecordDBQuery(ctx, "expire_export_jobs_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning expired export job: %v", err)
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	observability.RecordDBQuery(ctx, "expire_export_jobs", time.Since(start), nil)
+
+	return paths, nil
+}
+
+// CountExportRows estimates how many rows an export will write, for progress
+// reporting.
+func (d *Database) CountExportRows(kind string, query *models.TelemetryQuery) (int64, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	from, args := telemetryFilter(query)
+	if kind == models.ExportAnomalies {
+		from, args = anomalyFilter(query)
+	}
+
+	total, _, err := d.countRows(ctx, models.TotalEstimate, from, args)
+	observability.RecordDBQuery(ctx, "count_export_rows", time.Since(start), err)
+	if err != nil {
+		return 0, fmt.Errorf("error estimating export size: %v", err)
+	}
+	return int64(total), nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExportJob(row rowScanner) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var request []byte
+	var startedAt, finishedAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.Status,
+		&request,
+		&job.RowsEstimated,
+		&job.RowsWritten,
+		&job.BytesWritten,
+		&job.FilePath,
+		&job.Error,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(request, &job.Request); err != nil {
+		return nil, fmt.Errorf("error decoding export request: %v", err)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+	return &job, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+
+	"telemetry-api/internal/models"
+)
+
+// telemetryFilter builds the FROM ... WHERE clause and arguments for a
+// telemetry query. Predicate parameters have been checked against
+// models.Parameters, so they are safe to use as column names; every value is
+// bound as an argument and cast to real, the type the columns are stored in,
+// so that bounds compare as ValuePredicate.Matches does and eq can match.
+func telemetryFilter(query *models.TelemetryQuery) (string, []interface{}) {
+	from := `telemetry WHERE timestamp BETWEEN $1 AND $2`
+	args := []interface{}{query.StartTime, query.EndTime}
+
+	if len(query.SubsystemIDs) > 0 {
+		args = append(args, subsystemArray(query.SubsystemIDs))
+		from += fmt.Sprintf(" AND subsystem_id = ANY($%d)", len(args))
+	}
+
+	if query.HasAnomaly != nil {
+		args = append(args, *query.HasAnomaly)
+		from += fmt.Sprintf(" AND has_anomaly = $%d", len(args))
+	}
+
+	for _, p := range query.Predicates {
+		if p.Op == "between" {
+			args = append(args, p.Values[0], p.Values[1])
+			from += fmt.Sprintf(" AND %s BETWEEN $%d::real AND $%d::real", p.Parameter, len(args)-1, len(args))
+			continue
+		}
+		args = append(args, p.Values[0])
+		from += fmt.Sprintf(" AND %s %s $%d::real", p.Parameter, models.ValuePredicateOps[p.Op], len(args))
+	}
+
+	return from, args
+}
+
+// anomalyFilter builds the FROM ... WHERE clause and arguments for an
+// anomaly query.
+func anomalyFilter(query *models.TelemetryQuery) (string, []interface{}) {
+	from := `anomalies WHERE timestamp BETWEEN $1 AND $2`
+	args := []interface{}{query.StartTime, query.EndTime}
+
+	if len(query.SubsystemIDs) > 0 {
+		args = append(args, subsystemArray(query.SubsystemIDs))
+		from += fmt.Sprintf(" AND subsystem_id = ANY($%d)", len(args))
+	}
+
+	return from, args
+}
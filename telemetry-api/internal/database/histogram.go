@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// GetValueRange returns the smallest and largest value of a parameter over
+// the query's range. ok is false when there are no samples. The parameter
+// must already be validated against models.Parameters.
+func (d *Database) GetValueRange(query *models.TelemetryQuery, parameter string) (float64, float64, bool, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	from, args := telemetryFilter(query)
+	sqlQuery := fmt.Sprintf(`SELECT MIN(%[1]s)::float8, MAX(%[1]s)::float8 FROM %[2]s`, parameter, from)
+
+	var low, high sql.NullFloat64
+	err := d.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&low, &high)
+	observability.RecordDBQuery(ctx, "get_value_range", time.Since(start), err)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("error querying value range: %v", err)
+	}
+
+	return low.Float64, high.Float64, low.Valid && high.Valid, nil
+}
+
+// GetHistogram counts a parameter's values per subsystem in equal-width
+// bins over [low, high) using width_bucket. Each subsystem's counts have
+// bins+2 entries: index 0 is the underflow and index bins+1 the overflow.
+func (d *Database) GetHistogram(query *models.TelemetryQuery, parameter string, low, high float64, bins int) (map[uint16][]int, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	from, args := telemetryFilter(query)
+	args = append(args, low, high, bins)
+	n := len(args)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			subsystem_id,
+			width_bucket(%[1]s::float8, $%[3]d::float8, $%[4]d::float8, $%[5]d::int) as bin,
+			COUNT(*) as count
+		FROM %[2]s
+		GROUP BY subsystem_id, bin
+		ORDER BY subsystem_id, bin`, parameter, from, n-2, n-1, n)
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_histogram", time.Since(start), err)
+		return nil, fmt.Errorf("error querying histogram: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uint16][]int)
+	for rows.Next() {
+		var subsystemID uint16
+		var bin, count int
+		if err := rows.Scan(&subsystemID, &bin, &count); err != nil {
+			observability.RecordDBQuery(ctx, "get_histogram_scan", time.Since(start), err)
+			return nil, fmt.Errorf("error scanning histogram: %v", err)
+		}
+		if counts[subsystemID] == nil {
+			counts[subsystemID] = make([]int, bins+2)
+		}
+		counts[subsystemID][bin] = count
+	}
+
+	observability.RecordDBQuery(ctx, "get_histogram", time.Since(start), rows.Err())
+	return counts, rows.Err()
+}
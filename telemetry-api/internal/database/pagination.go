@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"telemetry-api/internal/models"
+)
+
+// pageClause appends keyset or offset paging to a query ordered newest first.
+// One extra row is fetched so the caller can tell whether another page exists.
+func pageClause(query *models.TelemetryQuery, args []interface{}) (string, []interface{}) {
+	var clause string
+	if query.After != nil {
+		args = append(args, query.After.Timestamp, query.After.ID)
+		clause += fmt.Sprintf(" AND (timestamp, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, query.PageSize+1)
+	clause += fmt.Sprintf(" ORDER BY timestamp DESC, id DESC LIMIT $%d", len(args))
+
+	if query.After == nil {
+		args = append(args, (query.Page-1)*query.PageSize)
+		clause += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return clause, args
+}
+
+// countRows counts the rows matching a filter according to the query's total
+// mode. Estimates come from the planner and cost nothing to compute.
+func (d *Database) countRows(ctx context.Context, mode, from string, args []interface{}) (int, bool, error) {
+	switch mode {
+	case models.TotalNone:
+		return 0, false, nil
+	case models.TotalEstimate:
+		var plan []byte
+		if err := d.db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) SELECT 1 FROM "+from, args...).Scan(&plan); err != nil {
+			return 0, false, err
+		}
+		var explain []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+		if err := json.Unmarshal(plan, &explain); err != nil || len(explain) == 0 {
+			return 0, false, fmt.Errorf("error parsing query plan: %v", err)
+		}
+		return int(explain[0].Plan.Rows), true, nil
+	default:
+		var count int
+		err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from, args...).Scan(&count)
+		return count, false, err
+	}
+}
+
+// finishPage trims the look-ahead row and fills in the paging metadata. last
+// returns the cursor for the row at index i.
+func finishPage(query *models.TelemetryQuery, total int, estimated bool, n int, last func(i int) models.Cursor) (int, models.PageInfo) {
+	info := models.PageInfo{TotalCount: total, TotalEstimated: estimated}
+
+	if n > query.PageSize {
+		n = query.PageSize
+		info.HasMore = true
+	}
+	if info.HasMore {
+		info.NextCursor = last(n - 1).Encode()
+	}
+	return n, info
+}
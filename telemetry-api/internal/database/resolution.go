@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+)
+
+// RawResolution is reported when a query reads the telemetry hypertable
+// directly rather than a continuous aggregate.
+const RawResolution = "raw"
+
+// resolution is one of the continuous aggregates created by migration 000002.
+type resolution struct {
+	name  string
+	table string
+	width time.Duration
+}
+
+// resolutions is ordered coarsest first.
+var resolutions = []resolution{
+	{name: "1d", table: "telemetry_1d", width: 24 * time.Hour},
+	{name: "1h", table: "telemetry_1h", width: time.Hour},
+	{name: "1m", table: "telemetry_1m", width: time.Minute},
+}
+
+// selectResolution returns the coarsest continuous aggregate whose buckets
+// evenly divide interval, or nil if the raw table must be used. Percentiles
+// cannot be derived from pre-aggregated state, so they always read raw rows.
+func selectResolution(interval time.Duration, functions []string) *resolution {
+	for _, function := range functions {
+		if _, ok := models.Percentile(function); ok {
+			return nil
+		}
+	}
+
+	for i := range resolutions {
+		if interval >= resolutions[i].width && interval%resolutions[i].width == 0 {
+			return &resolutions[i]
+		}
+	}
+	return nil
+}
+
+// startBound is the condition that keeps every bucket of r overlapping a
+// range starting at the timestamp placeholder param. A bucket is labelled with
+// its start, so the one holding an unaligned start time begins before it.
+func (r *resolution) startBound(param string) string {
+	return fmt.Sprintf("bucket >= time_bucket(make_interval(secs => %d), %s::timestamptz)", int64(r.width.Seconds()), param)
+}
+
+// aggregateExpr computes function over a raw telemetry column.
+func aggregateExpr(function, column string) string {
+	if p, ok := models.Percentile(function); ok {
+		return fmt.Sprintf("percentile_cont(%g) WITHIN GROUP (ORDER BY %s)", p, column)
+	}
+
+	switch function {
+	case "count":
+		return fmt.Sprintf("COUNT(%s)::float8", column)
+	case "stddev":
+		return fmt.Sprintf("STDDEV_SAMP(%s)", column)
+	case "first", "last":
+		return fmt.Sprintf("%s(%s, timestamp)::float8", function, column)
+	default:
+		return fmt.Sprintf("%s(%s)::float8", strings.ToUpper(function), column)
+	}
+}
+
+// rollupExpr computes function by re-aggregating a continuous aggregate's
+// per-bucket state for column.
+func rollupExpr(function, column string) string {
+	switch function {
+	case "min":
+		return fmt.Sprintf("MIN(%s_min)::float8", column)
+	case "max":
+		return fmt.Sprintf("MAX(%s_max)::float8", column)
+	case "avg":
+		return fmt.Sprintf("SUM(%s_sum) / NULLIF(SUM(sample_count), 0)", column)
+	case "count":
+		return "SUM(sample_count)::float8"
+	case "stddev":
+		return fmt.Sprintf(`CASE WHEN SUM(sample_count) > 1 THEN
+				SQRT(GREATEST((SUM(%[1]s_sumsq) - SUM(%[1]s_sum) ^ 2 / SUM(sample_count)) / (SUM(sample_count) - 1), 0))
+			END`, column)
+	default: // first, last
+		return fmt.Sprintf("%s(%s_%s, bucket)::float8", function, column, function)
+	}
+}
+
+// rangeResolution returns the coarsest continuous aggregate whose buckets
+// start and end exactly on the range bounds, so that reading whole buckets
+// covers [start, end) and nothing else, or nil if there is none.
+func rangeResolution(start, end time.Time) *resolution {
+	for i := range resolutions {
+		width := resolutions[i].width
+		if start.Truncate(width).Equal(start) && end.Truncate(width).Equal(end) {
+			return &resolutions[i]
+		}
+	}
+	return nil
+}
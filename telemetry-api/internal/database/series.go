@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// GetSeries returns one regular series per subsystem with a point for every
+// interval bucket in the range, using time_bucket_gapfill. Each point carries
+// the bucket average of each parameter; empty buckets are filled by the
+// query's strategy and flagged accordingly. Parameters and the interval must
+// already be validated against the models whitelists.
+func (d *Database) GetSeries(query *models.SeriesQuery, parameters []string) ([]models.Series, string, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	interval := models.AggregationIntervals[query.Interval]
+
+	table, timeColumn, countExpr := "telemetry", "timestamp", "COUNT(*)"
+	avg := func(column string) string { return aggregateExpr("avg", column) }
+	where := "timestamp >= $2 AND timestamp < $3"
+	resolutionName := RawResolution
+	if res := selectResolution(interval, []string{"avg"}); res != nil {
+		// Rollup buckets that straddle either edge are counted whole.
+		table, timeColumn, countExpr = res.table, "bucket", "SUM(sample_count)::bigint"
+		where = res.startBound("$2") + " AND bucket < $3"
+		avg = func(column string) string { return "(" + rollupExpr("avg", column) + ")::float8" }
+		resolutionName = res.name
+	}
+
+	var columns []string
+	for _, parameter := range parameters {
+		measured := avg(parameter)
+		filled := measured
+		switch query.Fill {
+		case models.FillLOCF:
+			filled = "locf(" + measured + ")"
+		case models.FillInterpolate:
+			filled = "interpolate(" + measured + ")"
+		}
+		columns = append(columns, measured, filled)
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			time_bucket_gapfill($1::interval, %[1]s, $2, $3) as bucket_start,
+			subsystem_id,
+			%[2]s as count,
+			%[3]s
+		FROM %[4]s
+		WHERE %[5]s`, timeColumn, countExpr, strings.Join(columns, ",\n\t\t\t"), table, where)
+
+	args := []interface{}{fmt.Sprintf("%d seconds", int64(interval.Seconds())), query.StartTime, query.EndTime}
+	if len(query.SubsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($4)"
+		args = append(args, subsystemArray(query.SubsystemIDs))
+	}
+
+	sqlQuery += " GROUP BY bucket_start, subsystem_id ORDER BY subsystem_id, bucket_start"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_series", time.Since(start), err)
+		return nil, "", fmt.Errorf("error querying series: %v", err)
+	}
+	defer rows.Close()
+
+	var series []models.Series
+	for rows.Next() {
+		var point models.SeriesPoint
+		var subsystemID uint16
+		var count sql.NullInt64
+		values := make([]sql.NullFloat64, len(columns))
+
+		dest := []interface{}{&point.Timestamp, &subsystemID, &count}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			observability.RecordDBQuery(ctx, "get_series_scan", time.Since(start), err)
+			return nil, "", fmt.Errorf("error scanning series point: %v", err)
+		}
+
+		point.Count = int(count.Int64)
+		point.Values = make(map[string]*float64, len(parameters))
+		point.Quality = make(map[string]string, len(parameters))
+		for i, parameter := range parameters {
+			measured, filled := values[2*i], values[2*i+1]
+			switch {
+			case measured.Valid:
+				v := measured.Float64
+				point.Values[parameter] = &v
+				point.Quality[parameter] = models.QualityMeasured
+			case filled.Valid:
+				v := filled.Float64
+				point.Values[parameter] = &v
+				point.Quality[parameter] = models.QualityCarried
+				if query.Fill == models.FillInterpolate {
+					point.Quality[parameter] = models.QualityInterpolated
+				}
+			default:
+				point.Values[parameter] = nil
+				point.Quality[parameter] = models.QualityMissing
+			}
+		}
+
+		if len(series) == 0 || series[len(series)-1].SubsystemID != subsystemID {
+			series = append(series, models.Series{SubsystemID: subsystemID})
+		}
+		series[len(series)-1].Points = append(series[len(series)-1].Points, point)
+	}
+
+	observability.RecordDBQuery(ctx, "get_series", time.Since(start), nil)
+
+	return series, resolutionName, nil
+}
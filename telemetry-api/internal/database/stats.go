@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/observability"
+)
+
+// rollupStats can be derived from continuous aggregate state; percentiles
+// and limit checks need the raw samples.
+var rollupStats = map[string]bool{"min": true, "max": true, "mean": true, "stddev": true, "count": true}
+
+// GetStats summarises each parameter per subsystem over [start, end). When
+// every requested statistic can be rolled up and the range is aligned to a
+// continuous aggregate's buckets, the aggregate is read instead of the raw
+// rows. Each sample counts towards out_of_limits_pct for the time until the
+// subsystem's next sample, so gaps and uneven spacing are weighted properly. It returns the statistics and the resolution used. Parameters and
+// statistics must already be validated against the models whitelists.
+func (d *Database) GetStats(query *models.StatsQuery, parameters, stats []string) ([]models.ParameterStats, string, error) {
+	ctx := context.Background()
+	start := time.Now()
+
+	table, timeColumn, countExpr := "telemetry", "timestamp", "COUNT(*)"
+	expr := rawStatExpr
+	resolutionName := RawResolution
+	if res := rangeResolution(query.StartTime, query.EndTime); res != nil && canRollUp(stats) {
+		table, timeColumn, countExpr = res.table, "bucket", "SUM(sample_count)::bigint"
+		expr = rollupStatExpr
+		resolutionName = res.name
+	} else if hasStat(stats, "out_of_limits_pct") {
+		table = heldTelemetry
+	}
+
+	var columns []string
+	for _, parameter := range parameters {
+		for _, stat := range stats {
+			columns = append(columns, expr(stat, parameter))
+		}
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			subsystem_id,
+			%[2]s as count,
+			%[3]s
+		FROM %[4]s
+		WHERE %[1]s >= $1 AND %[1]s < $2`, timeColumn, countExpr, strings.Join(columns, ",\n\t\t\t"), table)
+
+	args := []interface{}{query.StartTime, query.EndTime}
+	if len(query.SubsystemIDs) > 0 {
+		sqlQuery += " AND subsystem_id = ANY($3)"
+		args = append(args, subsystemArray(query.SubsystemIDs))
+	}
+
+	sqlQuery += " GROUP BY subsystem_id ORDER BY subsystem_id"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		observability.RecordDBQuery(ctx, "get_stats", time.Since(start), err)
+		return nil, "", fmt.Errorf("error querying telemetry stats: %v", err)
+	}
+	defer rows.Close()
+
+	var results []models.ParameterStats
+	for rows.Next() {
+		var result models.ParameterStats
+		values := make([]sql.NullFloat64, len(columns))
+
+		dest := []interface{}{&result.SubsystemID, &result.Count}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			observability.RecordDBQuery(ctx, "get_stats_scan", time.Since(start), err)
+			return nil, "", fmt.Errorf("error scanning telemetry stats: %v", err)
+		}
+
+		result.Values = make(map[string]map[string]float64, len(parameters))
+		for i, parameter := range parameters {
+			result.Values[parameter] = make(map[string]float64, len(stats))
+			for j, stat := range stats {
+				if v := values[i*len(stats)+j]; v.Valid {
+					result.Values[parameter][stat] = v.Float64
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	observability.RecordDBQuery(ctx, "get_stats", time.Since(start), nil)
+
+	return results, resolutionName, nil
+}
+
+// heldTelemetry adds to each raw row in [$1, $2) the seconds until the
+// subsystem's next sample, or until $2 for the last one, so that
+// out_of_limits_pct measures the share of time rather than of samples.
+const heldTelemetry = `(
+			SELECT *, EXTRACT(EPOCH FROM LEAST(lead(timestamp) OVER (PARTITION BY subsystem_id ORDER BY timestamp), $2) - timestamp)::float8 AS held
+			FROM telemetry
+			WHERE timestamp >= $1 AND timestamp < $2
+		) AS telemetry`
+
+func hasStat(stats []string, name string) bool {
+	for _, stat := range stats {
+		if stat == name {
+			return true
+		}
+	}
+	return false
+}
+
+func canRollUp(stats []string) bool {
+	for _, stat := range stats {
+		if !rollupStats[stat] {
+			return false
+		}
+	}
+	return true
+}
+
+func rawStatExpr(stat, column string) string {
+	switch stat {
+	case "mean":
+		return aggregateExpr("avg", column)
+	case "out_of_limits_pct":
+		limits := models.ParameterLimits[column]
+		return fmt.Sprintf("100 * SUM(CASE WHEN %[1]s < %[2]g OR %[1]s > %[3]g THEN held ELSE 0 END) / NULLIF(SUM(held), 0)",
+			column, limits.NormalMin, limits.NormalMax)
+	default:
+		return aggregateExpr(stat, column)
+	}
+}
+
+func rollupStatExpr(stat, column string) string {
+	if stat == "mean" {
+		stat = "avg"
+	}
+	return "(" + rollupExpr(stat, column) + ")::float8"
+}
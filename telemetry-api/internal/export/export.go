@@ -0,0 +1,236 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// parquetRowGroupSize bounds how many rows the Parquet writer buffers before
+// flushing a row group, keeping memory constant for any export size.
+const parquetRowGroupSize = 10000
+
+// Writer encodes a stream of records of a single kind.
+type Writer interface {
+	Write(record interface{}) error
+	Close() error
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON || format == FormatParquet
+}
+
+func ValidCompression(compression string) bool {
+	return compression == "" || compression == CompressionNone || compression == CompressionGzip
+}
+
+func ContentType(format, compression string) string {
+	if compression == CompressionGzip && format != FormatParquet {
+		return "application/gzip"
+	}
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// FileName suggests a download name for an export.
+func FileName(kind, format, compression string, start, end time.Time) string {
+	name := fmt.Sprintf("%s_%s_%s.%s", kind, start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z"), format)
+	if compression == CompressionGzip && format != FormatParquet {
+		name += ".gz"
+	}
+	return name
+}
+
+// NewWriter returns a writer for kind in the given format. Gzip wraps the
+// whole stream for text formats; Parquet compresses its column chunks instead.
+func NewWriter(w io.Writer, kind, format, compression string) (Writer, error) {
+	if format == FormatParquet {
+		codec := parquet.Compression(&parquet.Snappy)
+		if compression == CompressionGzip {
+			codec = parquet.Compression(&parquet.Gzip)
+		}
+		schema := parquet.SchemaOf(new(telemetryRow))
+		if kind == models.ExportAnomalies {
+			schema = parquet.SchemaOf(new(anomalyRow))
+		}
+		return &parquetWriter{
+			writer: parquet.NewWriter(w, schema, codec, parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		}, nil
+	}
+
+	var closers []io.Closer
+	if compression == CompressionGzip {
+		gz := gzip.NewWriter(w)
+		closers = append(closers, gz)
+		w = gz
+	}
+	buffered := bufio.NewWriter(w)
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(buffered)
+		header := telemetryHeader
+		if kind == models.ExportAnomalies {
+			header = anomalyHeader
+		}
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: cw, buffered: buffered, closers: closers}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(buffered), buffered: buffered, closers: closers}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+var telemetryHeader = []string{"id", "timestamp", "subsystem_id", "temperature", "battery", "altitude", "signal", "has_anomaly"}
+
+var anomalyHeader = []string{"id", "timestamp", "subsystem_id", "anomaly_type", "value", "expected_range"}
+
+type csvWriter struct {
+	writer   *csv.Writer
+	buffered *bufio.Writer
+	closers  []io.Closer
+}
+
+func (w *csvWriter) Write(record interface{}) error {
+	switch r := record.(type) {
+	case models.TelemetryRecord:
+		return w.writer.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.Timestamp.UTC().Format(time.RFC3339Nano),
+			strconv.Itoa(int(r.SubsystemID)),
+			formatFloat(r.Temperature),
+			formatFloat(r.Battery),
+			formatFloat(r.Altitude),
+			formatFloat(r.Signal),
+			strconv.FormatBool(r.HasAnomaly),
+		})
+	case models.AnomalyRecord:
+		return w.writer.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.Timestamp.UTC().Format(time.RFC3339Nano),
+			strconv.Itoa(int(r.SubsystemID)),
+			r.AnomalyType,
+			formatFloat(r.Value),
+			r.ExpectedRange,
+		})
+	}
+	return fmt.Errorf("unsupported record type %T", record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return err
+	}
+	return closeAll(w.buffered, w.closers)
+}
+
+type ndjsonWriter struct {
+	encoder  *json.Encoder
+	buffered *bufio.Writer
+	closers  []io.Closer
+}
+
+func (w *ndjsonWriter) Write(record interface{}) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return closeAll(w.buffered, w.closers)
+}
+
+type telemetryRow struct {
+	ID          int64     `parquet:"id"`
+	Timestamp   time.Time `parquet:"timestamp,timestamp(microsecond)"`
+	SubsystemID int32     `parquet:"subsystem_id"`
+	Temperature float32   `parquet:"temperature"`
+	Battery     float32   `parquet:"battery"`
+	Altitude    float32   `parquet:"altitude"`
+	Signal      float32   `parquet:"signal"`
+	HasAnomaly  bool      `parquet:"has_anomaly"`
+}
+
+type anomalyRow struct {
+	ID            int64     `parquet:"id"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(microsecond)"`
+	SubsystemID   int32     `parquet:"subsystem_id"`
+	AnomalyType   string    `parquet:"anomaly_type,dict"`
+	Value         float32   `parquet:"value"`
+	ExpectedRange string    `parquet:"expected_range,dict"`
+}
+
+type parquetWriter struct {
+	writer *parquet.Writer
+}
+
+func (w *parquetWriter) Write(record interface{}) error {
+	switch r := record.(type) {
+	case models.TelemetryRecord:
+		return w.writer.Write(&telemetryRow{
+			ID:          r.ID,
+			Timestamp:   r.Timestamp,
+			SubsystemID: int32(r.SubsystemID),
+			Temperature: r.Temperature,
+			Battery:     r.Battery,
+			Altitude:    r.Altitude,
+			Signal:      r.Signal,
+			HasAnomaly:  r.HasAnomaly,
+		})
+	case models.AnomalyRecord:
+		return w.writer.Write(&anomalyRow{
+			ID:            r.ID,
+			Timestamp:     r.Timestamp,
+			SubsystemID:   int32(r.SubsystemID),
+			AnomalyType:   r.AnomalyType,
+			Value:         r.Value,
+			ExpectedRange: r.ExpectedRange,
+		})
+	}
+	return fmt.Errorf("unsupported record type %T", record)
+}
+
+func (w *parquetWriter) Close() error {
+	return w.writer.Close()
+}
+
+func closeAll(buffered *bufio.Writer, closers []io.Closer) error {
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
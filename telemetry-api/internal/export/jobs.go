@@ -0,0 +1,324 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"telemetry-api/internal/database"
+	"telemetry-api/internal/models"
+)
+
+const (
+	pollInterval      = 5 * time.Second
+	cleanupInterval   = 15 * time.Minute
+	heartbeatInterval = 2 * time.Second // between progress updates and cancellation checks
+	leaseTimeout      = time.Minute     // heartbeat age after which a running job is requeued
+)
+
+var (
+	errCancelled = errors.New("export cancelled")
+	errRequeued  = errors.New("export requeued")
+)
+
+// Manager runs asynchronous export jobs. Job state lives in Postgres, and a
+// running job holds a lease renewed by its heartbeat, so a job whose worker
+// stopped is picked up again from the start by any instance.
+type Manager struct {
+	db        *database.Database
+	dir       string
+	retention time.Duration
+	wake      chan struct{}
+}
+
+// NewManager stores finished exports under dir and removes them retention
+// after they finish.
+func NewManager(db *database.Database, dir string, retention time.Duration) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating export directory: %v", err)
+	}
+	return &Manager{
+		db:        db,
+		dir:       dir,
+		retention: retention,
+		wake:      make(chan struct{}, 1),
+	}, nil
+}
+
+// Submit queues a new job.
+func (m *Manager) Submit(request models.ExportRequest) (*models.ExportJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := m.db.CreateExportJob(id, request)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Run works through queued jobs one at a time and removes expired files until
+// ctx is cancelled.
+func (m *Manager) Run(ctx context.Context) {
+	m.cleanup()
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		m.requeue()
+
+		for ctx.Err() == nil {
+			job, err := m.db.ClaimExportJob()
+			if err != nil {
+				log.Printf("Error claiming export job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			m.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-poll.C:
+		case <-cleanup.C:
+			m.cleanup()
+		}
+	}
+}
+
+func (m *Manager) run(ctx context.Context, job *models.ExportJob) {
+	log.Printf("Starting export job %s", job.ID)
+
+	path := filepath.Join(m.dir, job.ID+"_"+FileName(job.Request.Kind, job.Request.Format, job.Request.Compression, job.Request.StartTime, job.Request.EndTime))
+	rows, bytes, err := m.write(ctx, job, path)
+
+	switch {
+	case ctx.Err() != nil:
+		// Shutting down: leave the job running so its lease runs out and it
+		// is requeued.
+		os.Remove(path + ".tmp")
+		return
+	case errors.Is(err, errCancelled):
+		log.Printf("Export job %s cancelled", job.ID)
+		os.Remove(path + ".tmp")
+		return
+	case errors.Is(err, errRequeued):
+		log.Printf("Export job %s lost its lease and was requeued", job.ID)
+		os.Remove(path + ".tmp")
+		return
+	}
+
+	job.RowsWritten = rows
+	job.BytesWritten = bytes
+	expires := time.Now().Add(m.retention)
+	job.ExpiresAt = &expires
+
+	if err == nil {
+		job.Status = models.ExportCompleted
+		job.FilePath = path
+	} else {
+		log.Printf("Export job %s failed: %v", job.ID, err)
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+		os.Remove(path + ".tmp")
+	}
+
+	finished, err := m.db.FinishExportJob(job)
+	if err != nil {
+		log.Printf("Error recording export job %s: %v", job.ID, err)
+		return
+	}
+	if !finished {
+		// Cancelled or requeued after the last heartbeat, so nothing will
+		// ever serve or expire the file.
+		log.Printf("Export job %s was cancelled or requeued before it finished", job.ID)
+		os.Remove(path)
+	}
+}
+
+// write exports the job to a temporary file and renames it into place once
+// complete, so a download never sees a partial file. A heartbeat records
+// progress and stops the export if the job is cancelled or requeued.
+func (m *Manager) write(ctx context.Context, job *models.ExportJob, path string) (int64, int64, error) {
+	query, err := job.Request.Query()
+	if err != nil {
+		return 0, 0, err
+	}
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return 0, 0, err
+	}
+	units.CanonicalPredicates(query.Predicates)
+
+	estimated, err := m.db.CountExportRows(job.Request.Kind, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return 0, 0, fmt.Errorf("error creating export file: %v", err)
+	}
+	defer file.Close()
+
+	counter := &countingWriter{w: file}
+	writer, err := NewWriter(counter, job.Request.Kind, job.Request.Format, job.Request.Compression)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var rows atomic.Int64
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- m.heartbeat(jobCtx, job, estimated, &rows, counter)
+		cancel()
+	}()
+
+	if job.Request.Kind == models.ExportAnomalies {
+		err = m.db.StreamAnomalies(jobCtx, query, func(record models.AnomalyRecord) error {
+			units.ConvertAnomaly(&record)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+			rows.Add(1)
+			return nil
+		})
+	} else {
+		err = m.db.StreamTelemetry(jobCtx, query, func(record models.TelemetryRecord) error {
+			units.ConvertRecord(&record)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+			rows.Add(1)
+			return nil
+		})
+	}
+
+	cancel()
+	if stop := <-stopped; stop != nil && ctx.Err() == nil {
+		return rows.Load(), counter.bytes(), stop
+	}
+	if err != nil {
+		return rows.Load(), counter.bytes(), err
+	}
+
+	if err := writer.Close(); err != nil {
+		return rows.Load(), counter.bytes(), fmt.Errorf("error finishing export file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return rows.Load(), counter.bytes(), fmt.Errorf("error closing export file: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return rows.Load(), counter.bytes(), fmt.Errorf("error moving export file: %v", err)
+	}
+	return rows.Load(), counter.bytes(), nil
+}
+
+// heartbeat records progress every heartbeatInterval until ctx is done. It
+// returns errCancelled or errRequeued if the job stopped being this worker's
+// to run, and nil otherwise; errors recording progress are only logged, as
+// the lease has time to spare.
+func (m *Manager) heartbeat(ctx context.Context, job *models.ExportJob, estimated int64, rows *atomic.Int64, counter *countingWriter) error {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		written := rows.Load()
+		if written > estimated {
+			estimated = written
+		}
+		status, err := m.db.UpdateExportProgress(job.ID, *job.StartedAt, estimated, written, counter.bytes())
+		if err != nil {
+			log.Printf("Error updating export job %s: %v", job.ID, err)
+			continue
+		}
+		switch status {
+		case models.ExportRunning:
+		case models.ExportCancelled:
+			return errCancelled
+		default:
+			return errRequeued
+		}
+	}
+}
+
+// requeue returns jobs whose worker has stopped renewing their lease to the
+// queue.
+func (m *Manager) requeue() {
+	if n, err := m.db.RequeueExportJobs(leaseTimeout); err != nil {
+		log.Printf("Error requeueing export jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Requeued %d interrupted export jobs", n)
+	}
+}
+
+func (m *Manager) cleanup() {
+	paths, err := m.db.ExpireExportJobs(time.Now())
+	if err != nil {
+		log.Printf("Error expiring export jobs: %v", err)
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing expired export %s: %v", path, err)
+		}
+	}
+	if len(paths) > 0 {
+		log.Printf("Removed %d expired exports", len(paths))
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating export job id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// countingWriter counts the bytes written through it; the heartbeat reads
+// the count while the export writes.
+type countingWriter struct {
+	w io.Writer
+	n atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (c *countingWriter) bytes() int64 {
+	return c.n.Load()
+}
@@ -0,0 +1,120 @@
+// Package forecast extrapolates robust parameter trends to the limits of
+// their normal bands for the forecast endpoint.
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"telemetry-api/internal/analysis"
+	"telemetry-api/internal/models"
+)
+
+// Quantiles maps the accepted confidence levels to two-sided normal
+// quantiles.
+var Quantiles = map[float64]float64{
+	0.8:  1.2816,
+	0.9:  1.6449,
+	0.95: 1.9600,
+	0.99: 2.5758,
+}
+
+// Fit fits a Theil-Sen trend to one parameter of a series with x in hours
+// relative to at, so each line's intercept is its value at the forecast
+// origin, and extrapolates it to whichever edge of limits' normal band it is
+// heading for. z is the normal quantile of the slope interval; a crossing
+// within horizon sets WithinHorizon.
+func Fit(series models.Series, parameter string, limits models.Limits, at time.Time, interval time.Duration, z float64, horizon time.Duration) models.Forecast {
+	result := models.Forecast{
+		SubsystemID: series.SubsystemID,
+		Parameter:   parameter,
+		Status:      models.ForecastStable,
+	}
+
+	xs := make([]float64, len(series.Points))
+	ys := make([]float64, len(series.Points))
+	for i, point := range series.Points {
+		xs[i] = point.Timestamp.Add(interval / 2).Sub(at).Hours()
+		ys[i] = math.NaN()
+		if v := point.Values[parameter]; v != nil {
+			ys[i] = *v
+			result.Samples += point.Count
+		}
+	}
+
+	trend, ok := analysis.TheilSen(xs, ys, z)
+	if !ok {
+		return result
+	}
+	result.Fitted = floatPtr(trend.Intercept)
+	result.Slope = floatPtr(trend.Slope)
+	result.SlopeLow = floatPtr(trend.SlopeLow)
+	result.SlopeHigh = floatPtr(trend.SlopeHigh)
+
+	if trend.Intercept < limits.NormalMin || trend.Intercept > limits.NormalMax {
+		result.Status = models.ForecastViolating
+		result.HoursToLimit = floatPtr(0)
+		result.WithinHorizon = true
+		return result
+	}
+
+	var limit float64
+	switch {
+	case trend.Slope < 0:
+		limit = limits.NormalMin
+	case trend.Slope > 0:
+		limit = limits.NormalMax
+	default:
+		return result
+	}
+	result.Limit = floatPtr(limit)
+
+	hours, ok := hoursToLimit(trend.Intercept, trend.Slope, limit)
+	if !ok {
+		return result
+	}
+	result.Status = models.ForecastCrossing
+	result.HoursToLimit = floatPtr(hours)
+	result.ETA = timeAfter(at, hours)
+	result.WithinHorizon = horizon > 0 && hours <= horizon.Hours()
+
+	// The steeper bound reaches the limit first; the shallower one may never.
+	earliest, latest := hours, hours
+	bounded := true
+	for _, bound := range [][2]float64{{trend.InterceptLow, trend.SlopeLow}, {trend.InterceptHigh, trend.SlopeHigh}} {
+		h, ok := hoursToLimit(bound[0], bound[1], limit)
+		if !ok {
+			bounded = false
+			continue
+		}
+		earliest = math.Min(earliest, h)
+		latest = math.Max(latest, h)
+	}
+	result.ETAEarliest = timeAfter(at, earliest)
+	if bounded {
+		result.ETALatest = timeAfter(at, latest)
+	}
+	return result
+}
+
+// hoursToLimit solves intercept + slope*x = limit for x >= 0. A line already
+// past the limit reaches it immediately.
+func hoursToLimit(intercept, slope, limit float64) (float64, bool) {
+	if (slope < 0 && intercept <= limit) || (slope > 0 && intercept >= limit) {
+		return 0, true
+	}
+	if slope == 0 {
+		return 0, false
+	}
+	x := (limit - intercept) / slope
+	return x, x >= 0
+}
+
+func timeAfter(t time.Time, hours float64) *time.Time {
+	eta := t.Add(time.Duration(hours * float64(time.Hour)))
+	return &eta
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
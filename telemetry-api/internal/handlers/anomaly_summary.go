@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSummaryBuckets caps the histogram, which includes empty buckets.
+const maxSummaryBuckets = 10000
+
+// GetAnomalySummary returns anomaly counts by type, subsystem and time bucket
+// for a range, with the top parameters, the mean time between anomalies and a
+// comparison with the preceding period of the same length.
+func (h *Handlers) GetAnomalySummary(c *fiber.Ctx) error {
+	query := new(models.AnomalySummaryQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Summaries are counts, so there are no values to convert.
+	if c.Query("units") != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "units does not apply to anomaly summaries",
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	span := query.EndTime.Sub(query.StartTime)
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 100
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = models.IntervalFor(span, query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.GroupBy]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported group_by interval %q", query.GroupBy),
+		})
+	}
+	if span/interval > maxSummaryBuckets {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("group_by %s gives more than %d buckets; use a wider interval", query.GroupBy, maxSummaryBuckets),
+		})
+	}
+
+	counts, err := h.db.GetAnomalyCounts(query.StartTime, query.EndTime, interval, query.SubsystemIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to summarize anomalies",
+		})
+	}
+
+	current, err := h.db.GetAnomalyPeriod(query.StartTime, query.EndTime, query.SubsystemIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to summarize anomalies",
+		})
+	}
+
+	previous, err := h.db.GetAnomalyPeriod(query.StartTime.Add(-span), query.StartTime, query.SubsystemIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to summarize anomalies",
+		})
+	}
+
+	summary := summarizeAnomalies(counts, current, previous, query.StartTime, query.EndTime, interval)
+
+	response := models.TelemetryResponse{
+		Data: summaryTimes(summary, times),
+		Metadata: models.ResponseMetadata{
+			TotalCount: summary.Total,
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			GroupBy:    query.GroupBy,
+			TimeSystem: string(times.System),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// summarizeAnomalies folds the count cube into the summary's breakdowns.
+// Buckets follow time_bucket's alignment, which for the whitelisted intervals
+// matches time.Truncate.
+func summarizeAnomalies(counts []models.AnomalyCount, current, previous models.AnomalyPeriod, start, end time.Time, interval time.Duration) models.AnomalySummary {
+	summary := models.AnomalySummary{
+		AnomalyPeriod: current,
+		BySubsystem:   []models.SubsystemCount{},
+		TopParameters: []models.ParameterCount{},
+		Buckets:       []models.AnomalyBucket{},
+		Previous:      previous,
+		Change:        models.AnomalyChange{ByTypePct: map[string]float64{}},
+	}
+
+	bucketIndex := map[time.Time]int{}
+	for t := start.UTC().Truncate(interval); t.Before(end); t = t.Add(interval) {
+		bucketIndex[t] = len(summary.Buckets)
+		summary.Buckets = append(summary.Buckets, models.AnomalyBucket{Timestamp: t, ByType: map[string]int{}})
+	}
+
+	bySubsystem := map[uint16]int{}
+	byParameter := map[string]int{}
+	for _, count := range counts {
+		if i, ok := bucketIndex[count.Bucket.UTC()]; ok {
+			summary.Buckets[i].Count += count.Count
+			summary.Buckets[i].ByType[count.AnomalyType] += count.Count
+		}
+		bySubsystem[count.SubsystemID] += count.Count
+		byParameter[models.AnomalyParameter(count.AnomalyType)] += count.Count
+	}
+
+	for id, n := range bySubsystem {
+		summary.BySubsystem = append(summary.BySubsystem, models.SubsystemCount{SubsystemID: id, Count: n})
+	}
+	sort.Slice(summary.BySubsystem, func(i, j int) bool {
+		return summary.BySubsystem[i].SubsystemID < summary.BySubsystem[j].SubsystemID
+	})
+
+	for parameter, n := range byParameter {
+		summary.TopParameters = append(summary.TopParameters, models.ParameterCount{Parameter: parameter, Count: n})
+	}
+	sort.Slice(summary.TopParameters, func(i, j int) bool {
+		a, b := summary.TopParameters[i], summary.TopParameters[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Parameter < b.Parameter
+	})
+
+	if previous.Total > 0 {
+		pct := percentChange(previous.Total, current.Total)
+		summary.Change.TotalPct = &pct
+	}
+	for anomalyType, before := range previous.ByType {
+		if before > 0 {
+			summary.Change.ByTypePct[anomalyType] = percentChange(before, current.ByType[anomalyType])
+		}
+	}
+	return summary
+}
+
+func percentChange(before, after int) float64 {
+	return float64(after-before) / float64(before) * 100
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"telemetry-api/internal/analysis"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultChartPoints = 1000
+	// maxChartPoints bounds max_points, which sizes the buckets kept per
+	// series while the rows stream in.
+	maxChartPoints = 10000
+)
+
+// getTelemetryChart serves format=chart: the rows in the range that pass the
+// has_anomaly and value filters are streamed into a fixed number of time
+// buckets per subsystem and parameter, and the bucket extremes are then
+// downsampled with LTTB. The most extreme points of each excursion outside
+// the normal band are always kept, so excursions stay visible, and no series
+// holds more than max_points points.
+func (h *Handlers) getTelemetryChart(c *fiber.Ctx, query *models.TelemetryQuery, units models.Units) error {
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = defaultChartPoints
+	}
+	if query.MaxPoints > maxChartPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("max_points must be at most %d", maxChartPoints),
+		})
+	}
+
+	parameters := query.Projection
+	if len(parameters) == 0 {
+		parameters = models.Parameters
+	}
+
+	builders := make(map[uint16][]*chartBuilder)
+	var subsystems []uint16
+	total := 0
+	err := h.db.StreamTelemetry(c.UserContext(), query, func(record models.TelemetryRecord) error {
+		series, ok := builders[record.SubsystemID]
+		if !ok {
+			subsystems = append(subsystems, record.SubsystemID)
+			for _, parameter := range parameters {
+				series = append(series, newChartBuilder(parameter, query.StartTime, query.EndTime, query.MaxPoints))
+			}
+			builders[record.SubsystemID] = series
+		}
+		for _, b := range series {
+			b.add(record)
+		}
+		total++
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry data",
+		})
+	}
+
+	var series []models.ChartSeries
+	for _, id := range subsystems {
+		for _, b := range builders[id] {
+			series = append(series, b.series(id, units))
+		}
+	}
+
+	response := models.TelemetryResponse{
+		Data: series,
+		Metadata: models.ResponseMetadata{
+			TotalCount: total,
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			Units: units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// chartSample is one canonical value of a parameter.
+type chartSample struct {
+	id        int64
+	timestamp time.Time
+	value     float32
+}
+
+// chartBucket holds the lowest and highest sample in one time bucket.
+type chartBucket struct {
+	min, max chartSample
+	set      bool
+}
+
+func (b *chartBucket) add(s chartSample) {
+	if !b.set {
+		b.min, b.max, b.set = s, s, true
+		return
+	}
+	if s.value < b.min.value {
+		b.min = s
+	}
+	if s.value > b.max.value {
+		b.max = s
+	}
+}
+
+// chartBuilder reduces one parameter of one subsystem to a chart series in
+// memory bounded by max_points: maxPoints buckets of extremes, plus the
+// extremes of at most maxPoints/4 excursions. When more excursions occur,
+// neighbouring ones are merged, keeping the extremes across both.
+type chartBuilder struct {
+	parameter  string
+	limits     models.Limits
+	start      time.Time
+	span       time.Duration
+	maxPoints  int
+	buckets    []chartBucket
+	excursions []chartBucket
+	excursion  bool // the last sample was outside the normal band
+}
+
+func newChartBuilder(parameter string, start, end time.Time, maxPoints int) *chartBuilder {
+	return &chartBuilder{
+		parameter: parameter,
+		limits:    models.ParameterLimits[parameter],
+		start:     start,
+		span:      end.Sub(start),
+		maxPoints: maxPoints,
+		buckets:   make([]chartBucket, maxPoints),
+	}
+}
+
+func (b *chartBuilder) outOfBand(value float32) bool {
+	return b.limits.Severity(float64(value)) != models.SeverityNormal
+}
+
+func (b *chartBuilder) add(record models.TelemetryRecord) {
+	value, _ := record.Value(b.parameter)
+	s := chartSample{id: record.ID, timestamp: record.Timestamp, value: value}
+
+	i := 0
+	if b.span > 0 {
+		i = int(int64(s.timestamp.Sub(b.start)) * int64(len(b.buckets)) / int64(b.span))
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(b.buckets) {
+		i = len(b.buckets) - 1
+	}
+	b.buckets[i].add(s)
+
+	if !b.outOfBand(value) {
+		b.excursion = false
+		return
+	}
+	limit := b.maxPoints / 4
+	if limit == 0 {
+		return
+	}
+	if !b.excursion {
+		if len(b.excursions) == 2*limit {
+			b.mergeExcursions()
+		}
+		b.excursions = append(b.excursions, chartBucket{})
+		b.excursion = true
+	}
+	b.excursions[len(b.excursions)-1].add(s)
+}
+
+// mergeExcursions halves the excursions by merging neighbouring pairs.
+func (b *chartBuilder) mergeExcursions() {
+	merged := b.excursions[:0]
+	for i := 0; i < len(b.excursions); i += 2 {
+		e := b.excursions[i]
+		if i+1 < len(b.excursions) {
+			e.add(b.excursions[i+1].min)
+			e.add(b.excursions[i+1].max)
+		}
+		merged = append(merged, e)
+	}
+	b.excursions = merged
+}
+
+// series downsamples the bucket extremes with LTTB, forcing in the excursion
+// extremes, and converts only the points returned.
+func (b *chartBuilder) series(subsystemID uint16, units models.Units) models.ChartSeries {
+	for len(b.excursions) > b.maxPoints/4 {
+		b.mergeExcursions()
+	}
+
+	var samples []chartSample
+	forcedIDs := make(map[int64]bool)
+	for _, e := range b.excursions {
+		forcedIDs[e.min.id] = true
+		forcedIDs[e.max.id] = true
+		samples = append(samples, e.min, e.max)
+	}
+	for _, bucket := range b.buckets {
+		if bucket.set {
+			samples = append(samples, bucket.min, bucket.max)
+		}
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if !samples[i].timestamp.Equal(samples[j].timestamp) {
+			return samples[i].timestamp.Before(samples[j].timestamp)
+		}
+		return samples[i].id < samples[j].id
+	})
+
+	points := make([]analysis.Point, 0, len(samples))
+	unique := make([]chartSample, 0, len(samples))
+	var forced []int
+	for _, s := range samples {
+		// A sample can be both a bucket and an excursion extreme.
+		if len(unique) > 0 && s.id == unique[len(unique)-1].id {
+			continue
+		}
+		if forcedIDs[s.id] {
+			forced = append(forced, len(unique))
+		}
+		points = append(points, analysis.Point{X: float64(s.timestamp.UnixMilli()), Y: float64(s.value)})
+		unique = append(unique, s)
+	}
+
+	series := models.ChartSeries{SubsystemID: subsystemID, Parameter: b.parameter}
+	for _, i := range analysis.LTTBWithForced(points, b.maxPoints, forced) {
+		s := unique[i]
+		series.Points = append(series.Points, models.ChartPoint{
+			Timestamp: s.timestamp,
+			Value:     float32(units.Value(b.parameter, float64(s.value))),
+			Anomaly:   b.outOfBand(s.value),
+		})
+	}
+	return series
+}
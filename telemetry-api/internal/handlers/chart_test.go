@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"telemetry-api/internal/models"
+)
+
+func TestChartBuilder(t *testing.T) {
+	start := time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC)
+	const samples = 20000
+
+	tests := []struct {
+		name      string
+		maxPoints int
+		battery   func(i int) float32
+		// wantMin and wantMax must survive downsampling when set.
+		wantMin, wantMax float32
+	}{
+		{name: "in band", maxPoints: 100, battery: func(i int) float32 { return 80 + float32(i%10) }},
+		{
+			name:      "one long excursion",
+			maxPoints: 100,
+			battery: func(i int) float32 {
+				if i >= 5000 && i < 15000 {
+					if i == 9000 {
+						return 12
+					}
+					return 50 + float32(i%7)
+				}
+				return 85
+			},
+			wantMin: 12, wantMax: 85,
+		},
+		{
+			name:      "many short excursions",
+			maxPoints: 100,
+			battery: func(i int) float32 {
+				switch {
+				case i == 123:
+					return 5
+				case i%10 == 0:
+					return 60
+				}
+				return 85
+			},
+			wantMin: 5,
+		},
+		{name: "tiny budget", maxPoints: 4, battery: func(i int) float32 { return 60 + float32(i%30) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newChartBuilder("battery", start, start.Add(samples*time.Second), tt.maxPoints)
+			for i := 0; i < samples; i++ {
+				b.add(models.TelemetryRecord{ID: int64(i + 1), Timestamp: start.Add(time.Duration(i) * time.Second), Battery: tt.battery(i)})
+			}
+			if len(b.excursions) > tt.maxPoints/2 {
+				t.Errorf("holding %d excursions, want at most %d", len(b.excursions), tt.maxPoints/2)
+			}
+
+			units, _ := models.ParseUnits("")
+			series := b.series(7, units)
+			if n := len(series.Points); n > tt.maxPoints || n < 2 {
+				t.Fatalf("got %d points, want 2 to %d", n, tt.maxPoints)
+			}
+
+			found := map[float32]bool{}
+			for i, p := range series.Points {
+				if i > 0 && !p.Timestamp.After(series.Points[i-1].Timestamp) {
+					t.Fatalf("points not in time order at %d", i)
+				}
+				if p.Anomaly != (p.Value < 70) {
+					t.Errorf("point %v: anomaly flag %v", p.Value, p.Anomaly)
+				}
+				found[p.Value] = true
+			}
+			for _, want := range []float32{tt.wantMin, tt.wantMax} {
+				if want != 0 && !found[want] {
+					t.Errorf("extreme %g not kept", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CompareTelemetry lines up two windows of equal length on time since their
+// start (e.g. this orbit against the last one) and returns the paired bucket
+// averages with statistics of their difference.
+func (h *Handlers) CompareTelemetry(c *fiber.Ctx) error {
+	query := new(models.CompareQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time":          &query.StartTime,
+		"end_time":            &query.EndTime,
+		"baseline_start_time": &query.BaselineStartTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	span := query.EndTime.Sub(query.StartTime)
+
+	switch {
+	case !query.BaselineStartTime.IsZero() && query.Offset != "":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "give either baseline_start_time or offset, not both",
+		})
+	case query.Offset != "":
+		offset, err := time.ParseDuration(query.Offset)
+		if err != nil || offset <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "offset must be a positive duration such as '24h'",
+			})
+		}
+		query.BaselineStartTime = query.StartTime.Add(-offset)
+	case query.BaselineStartTime.IsZero():
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "baseline_start_time or offset is required",
+		})
+	}
+	baselineEnd := query.BaselineStartTime.Add(span)
+
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 500
+	}
+	if query.Interval == "" {
+		query.Interval = models.IntervalFor(span, query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.Interval]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported interval %q", query.Interval),
+		})
+	}
+	n := int((span + interval - 1) / interval)
+	if n > maxSeriesPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("interval %s gives more than %d points; use a wider interval", query.Interval, maxSeriesPoints),
+		})
+	}
+
+	parameters, err := parseParameters(query.Parameters)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	current, err := h.db.GetRelativeBuckets(&models.TelemetryQuery{
+		StartTime:    query.StartTime,
+		EndTime:      query.EndTime,
+		SubsystemIDs: query.SubsystemIDs,
+	}, interval, parameters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry for comparison",
+		})
+	}
+
+	baseline, err := h.db.GetRelativeBuckets(&models.TelemetryQuery{
+		StartTime:    query.BaselineStartTime,
+		EndTime:      baselineEnd,
+		SubsystemIDs: query.SubsystemIDs,
+	}, interval, parameters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry for comparison",
+		})
+	}
+
+	// Conversions are linear, so converting the bucket averages converts
+	// every difference statistic too.
+	for _, buckets := range [][]models.RelativeBucket{current, baseline} {
+		for _, bucket := range buckets {
+			for p, v := range bucket.Values {
+				bucket.Values[p] = units.Value(p, v)
+			}
+		}
+	}
+
+	comparisons := compareWindows(current, baseline, n, interval, parameters)
+
+	response := models.TelemetryResponse{
+		Data: comparisons,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(comparisons),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			BaselineRange: &models.TimeRange{
+				Start: query.BaselineStartTime,
+				End:   baselineEnd,
+			},
+			GroupBy: query.Interval,
+			Units:   units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// compareWindows pairs the buckets of both windows per subsystem. Buckets at
+// or past n come from the inclusive end bound and are dropped.
+func compareWindows(current, baseline []models.RelativeBucket, n int, interval time.Duration, parameters []string) []models.Comparison {
+	bySubsystem := make(map[uint16]*models.Comparison)
+	comparison := func(id uint16) *models.Comparison {
+		if c, ok := bySubsystem[id]; ok {
+			return c
+		}
+		c := &models.Comparison{SubsystemID: id, Points: make([]models.ComparisonPoint, n)}
+		for i := range c.Points {
+			c.Points[i] = models.ComparisonPoint{
+				OffsetSeconds: (time.Duration(i) * interval).Seconds(),
+				Current:       make(map[string]*float64, len(parameters)),
+				Baseline:      make(map[string]*float64, len(parameters)),
+			}
+			for _, p := range parameters {
+				c.Points[i].Current[p] = nil
+				c.Points[i].Baseline[p] = nil
+			}
+		}
+		bySubsystem[id] = c
+		return c
+	}
+
+	fill := func(buckets []models.RelativeBucket, side func(*models.ComparisonPoint) map[string]*float64) {
+		for _, bucket := range buckets {
+			if bucket.Index < 0 || bucket.Index >= n {
+				continue
+			}
+			values := side(&comparison(bucket.SubsystemID).Points[bucket.Index])
+			for p, v := range bucket.Values {
+				v := v
+				values[p] = &v
+			}
+		}
+	}
+	fill(current, func(p *models.ComparisonPoint) map[string]*float64 { return p.Current })
+	fill(baseline, func(p *models.ComparisonPoint) map[string]*float64 { return p.Baseline })
+
+	comparisons := make([]models.Comparison, 0, len(bySubsystem))
+	for _, c := range bySubsystem {
+		c.Stats = make(map[string]models.DifferenceStats, len(parameters))
+		for _, p := range parameters {
+			c.Stats[p] = differenceStats(c.Points, p)
+		}
+		comparisons = append(comparisons, *c)
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].SubsystemID < comparisons[j].SubsystemID
+	})
+	return comparisons
+}
+
+func differenceStats(points []models.ComparisonPoint, parameter string) models.DifferenceStats {
+	var stats models.DifferenceStats
+	var sumCurrent, sumBaseline, sumDiff, sumAbs, sumSquares, maxAbs, maxAbsOffset float64
+
+	for _, point := range points {
+		current, baseline := point.Current[parameter], point.Baseline[parameter]
+		if current == nil || baseline == nil {
+			continue
+		}
+		diff := *current - *baseline
+		stats.Pairs++
+		sumCurrent += *current
+		sumBaseline += *baseline
+		sumDiff += diff
+		sumAbs += math.Abs(diff)
+		sumSquares += diff * diff
+		if math.Abs(diff) >= maxAbs {
+			maxAbs, maxAbsOffset = math.Abs(diff), point.OffsetSeconds
+		}
+	}
+
+	if stats.Pairs == 0 {
+		return stats
+	}
+	n := float64(stats.Pairs)
+	stats.CurrentMean = floatPtr(sumCurrent / n)
+	stats.BaselineMean = floatPtr(sumBaseline / n)
+	stats.MeanDiff = floatPtr(sumDiff / n)
+	stats.MeanAbsDiff = floatPtr(sumAbs / n)
+	stats.RMSDiff = floatPtr(math.Sqrt(sumSquares / n))
+	stats.MaxAbsDiff = floatPtr(maxAbs)
+	stats.MaxAbsDiffOffsetSeconds = floatPtr(maxAbsOffset)
+	return stats
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"telemetry-api/internal/analysis"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCorrelation correlates two parameters per subsystem. Both are first
+// averaged into the same gap-filled buckets, so irregular sampling does not
+// weight the result; empty buckets are skipped rather than filled.
+func (h *Handlers) GetCorrelation(c *fiber.Ctx) error {
+	query := new(models.CorrelationQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !models.IsParameter(query.X) || !models.IsParameter(query.Y) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "x and y must each be one of the telemetry parameters",
+		})
+	}
+
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 500
+	}
+	if query.Interval == "" {
+		query.Interval = models.IntervalFor(query.EndTime.Sub(query.StartTime), query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.Interval]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported interval %q", query.Interval),
+		})
+	}
+	buckets := query.EndTime.Sub(query.StartTime) / interval
+	if buckets > maxSeriesPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("interval %s gives more than %d points; use a wider interval", query.Interval, maxSeriesPoints),
+		})
+	}
+	if query.MaxLag < 0 || time.Duration(query.MaxLag) >= buckets {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "max_lag must be between 0 and the number of buckets",
+		})
+	}
+
+	series, resolution, err := h.db.GetSeries(&models.SeriesQuery{
+		StartTime:    query.StartTime,
+		EndTime:      query.EndTime,
+		Interval:     query.Interval,
+		Fill:         models.FillNull,
+		SubsystemIDs: query.SubsystemIDs,
+	}, []string{query.X, query.Y})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry for correlation",
+		})
+	}
+
+	correlations := make([]models.Correlation, 0, len(series))
+	for _, s := range series {
+		correlations = append(correlations, correlate(s, query.X, query.Y, query.MaxLag, interval))
+	}
+
+	response := models.TelemetryResponse{
+		Data: correlations,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(correlations),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			GroupBy:    query.Interval,
+			Resolution: resolution,
+		},
+	}
+
+	return c.JSON(response)
+}
+
+func correlate(series models.Series, x, y string, maxLag int, interval time.Duration) models.Correlation {
+	xs := make([]float64, len(series.Points))
+	ys := make([]float64, len(series.Points))
+	for i, point := range series.Points {
+		xs[i], ys[i] = math.NaN(), math.NaN()
+		if v := point.Values[x]; v != nil {
+			xs[i] = *v
+		}
+		if v := point.Values[y]; v != nil {
+			ys[i] = *v
+		}
+	}
+
+	pearson, pairs := analysis.Pearson(xs, ys)
+	spearman, _ := analysis.Spearman(xs, ys)
+	correlation := models.Correlation{
+		SubsystemID: series.SubsystemID,
+		X:           x,
+		Y:           y,
+		Pairs:       pairs,
+		Pearson:     definedPtr(pearson),
+		Spearman:    definedPtr(spearman),
+	}
+
+	if maxLag == 0 {
+		return correlation
+	}
+	for _, lag := range analysis.CrossCorrelation(xs, ys, maxLag) {
+		lagged := models.LagCorrelation{
+			Lag:        lag.Lag,
+			LagSeconds: (time.Duration(lag.Lag) * interval).Seconds(),
+			Pearson:    definedPtr(lag.R),
+			Pairs:      lag.Pairs,
+		}
+		correlation.Lagged = append(correlation.Lagged, lagged)
+		if lagged.Pearson != nil && (correlation.BestLag == nil || math.Abs(*lagged.Pearson) > math.Abs(*correlation.BestLag.Pearson)) {
+			best := lagged
+			correlation.BestLag = &best
+		}
+	}
+	return correlation
+}
+
+// definedPtr returns nil for NaN, which JSON cannot represent.
+func definedPtr(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultCadence matches the generator's one sample per second.
+const defaultCadence = time.Second
+
+// GetCoverage reports, per subsystem, the contiguous intervals of telemetry,
+// the gaps between them and the share of the range covered. A gap is any
+// spacing between samples longer than the subsystem's cadence times
+// tolerance.
+func (h *Handlers) GetCoverage(c *fiber.Ctx) error {
+	query := new(models.CoverageQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	defaultCad, cadences, err := parseCadence(query.Cadence)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.Tolerance == 0 {
+		query.Tolerance = 1.5
+	}
+	if query.Tolerance < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "tolerance must be at least 1",
+		})
+	}
+
+	threshold := func(cadence time.Duration) time.Duration {
+		return time.Duration(float64(cadence) * query.Tolerance)
+	}
+	thresholds := make(map[uint16]time.Duration, len(cadences))
+	for id, cadence := range cadences {
+		thresholds[id] = threshold(cadence)
+	}
+
+	spans, err := h.db.GetSubsystemSpans(query.StartTime, query.EndTime, query.SubsystemIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry coverage",
+		})
+	}
+
+	gaps, err := h.db.GetGaps(query.StartTime, query.EndTime, query.SubsystemIDs, threshold(defaultCad), thresholds)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry coverage",
+		})
+	}
+
+	// Requested subsystems without a single sample are reported as one gap.
+	bySubsystem := make(map[uint16]models.SubsystemSpan, len(spans))
+	for _, span := range spans {
+		bySubsystem[span.SubsystemID] = span
+	}
+	for _, id := range query.SubsystemIDs {
+		if _, ok := bySubsystem[id]; !ok {
+			bySubsystem[id] = models.SubsystemSpan{SubsystemID: id}
+		}
+	}
+
+	gapsBySubsystem := make(map[uint16][]models.GapRow)
+	for _, gap := range gaps {
+		gapsBySubsystem[gap.SubsystemID] = append(gapsBySubsystem[gap.SubsystemID], gap)
+	}
+
+	coverage := make([]models.Coverage, 0, len(bySubsystem))
+	for id, span := range bySubsystem {
+		cadence := defaultCad
+		if cad, ok := cadences[id]; ok {
+			cadence = cad
+		}
+		coverage = append(coverage, buildCoverage(span, gapsBySubsystem[id], query.StartTime, query.EndTime, cadence, threshold(cadence)))
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].SubsystemID < coverage[j].SubsystemID
+	})
+
+	response := models.TelemetryResponse{
+		Data: coverage,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(coverage),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// buildCoverage adds the gaps at either edge of the range to those between
+// samples, derives the received intervals between them and the coverage.
+func buildCoverage(span models.SubsystemSpan, inner []models.GapRow, start, end time.Time, cadence, threshold time.Duration) models.Coverage {
+	coverage := models.Coverage{
+		SubsystemID:     span.SubsystemID,
+		CadenceSeconds:  cadence.Seconds(),
+		Samples:         span.Samples,
+		ExpectedSamples: int(end.Sub(start) / cadence),
+		Intervals:       []models.Interval{},
+		Gaps:            []models.Gap{},
+	}
+
+	if span.Samples == 0 {
+		if end.After(start) {
+			coverage.Gaps = append(coverage.Gaps, newGap(start, end, models.GapUnknown))
+		}
+		return coverage
+	}
+
+	if span.First.Sub(start) > threshold {
+		coverage.Gaps = append(coverage.Gaps, newGap(start, span.First, models.GapUnknown))
+	}
+
+	intervalStart := span.First
+	for _, gap := range inner {
+		coverage.Intervals = append(coverage.Intervals, newInterval(intervalStart, gap.Start))
+		coverage.Gaps = append(coverage.Gaps, newGap(gap.Start, gap.End, gap.Cause))
+		intervalStart = gap.End
+	}
+	coverage.Intervals = append(coverage.Intervals, newInterval(intervalStart, span.Last))
+
+	if end.Sub(span.Last) > threshold {
+		coverage.Gaps = append(coverage.Gaps, newGap(span.Last, end, models.GapUnknown))
+	}
+
+	var missing time.Duration
+	for _, gap := range coverage.Gaps {
+		missing += gap.End.Sub(gap.Start)
+	}
+	coverage.CoveragePct = 100 * (1 - missing.Seconds()/end.Sub(start).Seconds())
+	return coverage
+}
+
+func newGap(start, end time.Time, cause string) models.Gap {
+	return models.Gap{Start: start, End: end, DurationSeconds: end.Sub(start).Seconds(), Cause: cause}
+}
+
+func newInterval(start, end time.Time) models.Interval {
+	return models.Interval{Start: start, End: end, DurationSeconds: end.Sub(start).Seconds()}
+}
+
+// parseCadence reads a default cadence and per-subsystem overrides written
+// as id:duration, e.g. '1s,3:5s'.
+func parseCadence(value string) (time.Duration, map[uint16]time.Duration, error) {
+	cadence := defaultCadence
+	overrides := make(map[uint16]time.Duration)
+
+	for _, item := range splitList(value) {
+		id, duration, perSubsystem := strings.Cut(item, ":")
+		if !perSubsystem {
+			duration = item
+		}
+
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return 0, nil, fmt.Errorf("invalid cadence %q", item)
+		}
+		if !perSubsystem {
+			cadence = d
+			continue
+		}
+
+		n, err := strconv.ParseUint(id, 10, 16)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid subsystem in cadence %q", item)
+		}
+		overrides[uint16(n)] = d
+	}
+	return cadence, overrides, nil
+}
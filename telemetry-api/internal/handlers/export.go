@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"time"
+
+	"telemetry-api/internal/export"
+	"telemetry-api/internal/middleware"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportTelemetry streams every record in the requested range as CSV, NDJSON
+// or Parquet. It accepts the same filters as GetTelemetry but ignores paging.
+func (h *Handlers) ExportTelemetry(c *fiber.Ctx) error {
+	return h.export(c, models.ExportTelemetry)
+}
+
+// ExportAnomalies streams every anomaly in the requested range.
+func (h *Handlers) ExportAnomalies(c *fiber.Ctx) error {
+	return h.export(c, models.ExportAnomalies)
+}
+
+func (h *Handlers) export(c *fiber.Ctx, kind string) error {
+	query := &models.TelemetryQuery{}
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	// Export files always carry every column, so a projection is refused
+	// rather than silently ignored.
+	if query.Fields != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "fields is not supported by exports",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Exports exist for bulk data, so they are not held to the query range limit.
+	if err := checkTimeRange(query.StartTime, query.EndTime, 0); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.Format == "" {
+		query.Format = export.FormatCSV
+	}
+	if !export.ValidFormat(query.Format) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be 'csv', 'ndjson' or 'parquet'",
+		})
+	}
+	if !export.ValidCompression(query.Compression) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "compression must be 'gzip' or 'none'",
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if kind == models.ExportTelemetry {
+		if err := parseValuePredicates(c, query); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		units.CanonicalPredicates(query.Predicates)
+	}
+
+	filename := export.FileName(kind, query.Format, query.Compression, query.StartTime, query.EndTime)
+	c.Set(fiber.HeaderContentType, export.ContentType(query.Format, query.Compression))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Telemetry-Units", unitsHeader(units))
+
+	// The stream writer runs after the handler returns, when c is no longer
+	// valid, so take the tracing middleware's context now. It carries the
+	// request's trace but is never cancelled; a client that hangs up is
+	// noticed by the next failed write instead.
+	ctx := middleware.GetContext(c)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := export.NewWriter(w, kind, query.Format, query.Compression)
+		if err != nil {
+			log.Printf("Error starting %s export: %v", kind, err)
+			return
+		}
+
+		// The status and headers are already sent, so a failure part way
+		// through can only be logged; the client sees a truncated file. A
+		// client that hangs up fails the next write, which ends the cursor.
+		if kind == models.ExportAnomalies {
+			err = h.db.StreamAnomalies(ctx, query, func(record models.AnomalyRecord) error {
+				units.ConvertAnomaly(&record)
+				return writer.Write(record)
+			})
+		} else {
+			err = h.db.StreamTelemetry(ctx, query, func(record models.TelemetryRecord) error {
+				units.ConvertRecord(&record)
+				return writer.Write(record)
+			})
+		}
+		if err != nil {
+			log.Printf("Error exporting %s: %v", kind, err)
+			return
+		}
+
+		if err := writer.Close(); err != nil {
+			log.Printf("Error finishing %s export: %v", kind, err)
+			return
+		}
+		w.Flush()
+	})
+
+	return nil
+}
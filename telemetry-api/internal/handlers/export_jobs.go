@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+
+	"telemetry-api/internal/export"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateExport queues an asynchronous export for ranges too large to stream
+// within an HTTP timeout.
+func (h *Handlers) CreateExport(c *fiber.Ctx) error {
+	request := models.ExportRequest{}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateExportRequest(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	job, err := h.exports.Submit(request)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create export job",
+		})
+	}
+
+	c.Location(exportURL(job.ID))
+	return c.Status(fiber.StatusAccepted).JSON(describeExportJob(job))
+}
+
+func (h *Handlers) GetExport(c *fiber.Ctx) error {
+	job, err := h.db.GetExportJob(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch export job",
+		})
+	}
+	if job == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export job not found",
+		})
+	}
+
+	return c.JSON(describeExportJob(job))
+}
+
+// CancelExport stops a pending or running job. The worker notices at its next
+// heartbeat and discards the partial file.
+func (h *Handlers) CancelExport(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	cancelled, err := h.db.CancelExportJob(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel export job",
+		})
+	}
+
+	job, err := h.db.GetExportJob(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch export job",
+		})
+	}
+	if job == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export job not found",
+		})
+	}
+	if !cancelled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("Export job is already %s", job.Status),
+		})
+	}
+
+	return c.JSON(describeExportJob(job))
+}
+
+func (h *Handlers) DownloadExport(c *fiber.Ctx) error {
+	job, err := h.db.GetExportJob(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch export job",
+		})
+	}
+	if job == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export job not found",
+		})
+	}
+
+	switch job.Status {
+	case models.ExportCompleted:
+	case models.ExportExpired:
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Export has expired",
+		})
+	default:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("Export job is %s", job.Status),
+		})
+	}
+
+	request := job.Request
+	if units, err := models.ParseUnits(request.Units); err == nil {
+		c.Set("X-Telemetry-Units", unitsHeader(units))
+	}
+	c.Set(fiber.HeaderContentType, export.ContentType(request.Format, request.Compression))
+	return c.Download(job.FilePath, export.FileName(request.Kind, request.Format, request.Compression, request.StartTime, request.EndTime))
+}
+
+func validateExportRequest(request *models.ExportRequest) error {
+	if request.Kind == "" {
+		request.Kind = models.ExportTelemetry
+	}
+	if request.Kind != models.ExportTelemetry && request.Kind != models.ExportAnomalies {
+		return fmt.Errorf("kind must be 'telemetry' or 'anomalies'")
+	}
+
+	if request.Format == "" {
+		request.Format = export.FormatCSV
+	}
+	if !export.ValidFormat(request.Format) {
+		return fmt.Errorf("format must be 'csv', 'ndjson' or 'parquet'")
+	}
+	if !export.ValidCompression(request.Compression) {
+		return fmt.Errorf("compression must be 'gzip' or 'none'")
+	}
+
+	if err := checkTimeRange(request.StartTime, request.EndTime, 0); err != nil {
+		return err
+	}
+
+	if request.Kind == models.ExportAnomalies && (request.HasAnomaly != nil || len(request.Filters) > 0) {
+		return fmt.Errorf("has_anomaly and filters apply to telemetry exports only")
+	}
+	if _, err := models.ParseUnits(request.Units); err != nil {
+		return err
+	}
+	_, err := request.Query()
+	return err
+}
+
+// describeExportJob fills in the fields derived for clients: progress and,
+// once the file is ready, where to download it.
+func describeExportJob(job *models.ExportJob) *models.ExportJob {
+	switch job.Status {
+	case models.ExportCompleted:
+		job.Progress = 1
+		job.DownloadURL = exportURL(job.ID) + "/download"
+	case models.ExportRunning:
+		if job.RowsEstimated > 0 {
+			job.Progress = float64(job.RowsWritten) / float64(job.RowsEstimated)
+			if job.Progress > 0.99 {
+				job.Progress = 0.99
+			}
+		}
+	}
+	return job
+}
+
+func exportURL(id string) string {
+	return "/api/v1/exports/" + id
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/forecast"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxForecastPoints bounds the fit, which compares every pair of buckets.
+const maxForecastPoints = 2000
+
+// GetForecast fits a Theil-Sen trend to a parameter over a lookback window
+// and predicts when it will leave its normal band.
+func (h *Handlers) GetForecast(c *fiber.Ctx) error {
+	query := new(models.ForecastQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"at": &query.At,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !models.IsParameter(query.Parameter) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "parameter must be one of the telemetry parameters",
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.At.IsZero() {
+		query.At = time.Now().UTC()
+	}
+
+	if query.Lookback == "" {
+		query.Lookback = "24h"
+	}
+	lookback, err := time.ParseDuration(query.Lookback)
+	if err != nil || lookback <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "lookback must be a positive duration such as '24h'",
+		})
+	}
+	if h.maxRange > 0 && lookback > h.maxRange {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("lookback of %s exceeds the maximum of %s", lookback, h.maxRange),
+		})
+	}
+
+	var horizon time.Duration
+	if query.Horizon != "" {
+		horizon, err = time.ParseDuration(query.Horizon)
+		if err != nil || horizon <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "horizon must be a positive duration such as '72h'",
+			})
+		}
+	}
+
+	if query.Confidence == 0 {
+		query.Confidence = 0.95
+	}
+	z, ok := forecast.Quantiles[query.Confidence]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "confidence must be 0.8, 0.9, 0.95 or 0.99",
+		})
+	}
+
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 500
+	}
+	if query.Interval == "" {
+		query.Interval = models.IntervalFor(lookback, query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.Interval]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported interval %q", query.Interval),
+		})
+	}
+	if lookback/interval > maxForecastPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("interval %s gives more than %d points; use a wider interval", query.Interval, maxForecastPoints),
+		})
+	}
+
+	start := query.At.Add(-lookback)
+	series, resolution, err := h.db.GetSeries(&models.SeriesQuery{
+		StartTime:    start,
+		EndTime:      query.At,
+		Interval:     query.Interval,
+		Fill:         models.FillNull,
+		SubsystemIDs: query.SubsystemIDs,
+	}, []string{query.Parameter})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry for forecast",
+		})
+	}
+
+	limits := units.Limits(query.Parameter)
+	forecasts := make([]models.Forecast, 0, len(series))
+	for _, s := range series {
+		for _, point := range s.Points {
+			convertValues(point.Values, units)
+		}
+		forecasts = append(forecasts, forecast.Fit(s, query.Parameter, limits, query.At, interval, z, horizon))
+	}
+
+	response := models.TelemetryResponse{
+		Data: forecasts,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(forecasts),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: start,
+				End:   query.At,
+			},
+			GroupBy:    query.Interval,
+			Resolution: resolution,
+			Units:      units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
@@ -1,18 +1,28 @@
 package handlers
 
 import (
+	"fmt"
+	"time"
+
 	"telemetry-api/internal/database"
+	"telemetry-api/internal/export"
 	"telemetry-api/internal/models"
+	"telemetry-api/internal/realtime"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type Handlers struct {
-	db *database.Database
+	db       *database.Database
+	listener *realtime.Listener
+	exports  *export.Manager
+	maxRange time.Duration // longest start_time to end_time span; zero for no limit
+
+	missionEpoch time.Time // UTC origin of Mission Elapsed Time; zero if unset
 }
 
-func NewHandlers(db *database.Database) *Handlers {
-	return &Handlers{db: db}
+func NewHandlers(db *database.Database, listener *realtime.Listener, exports *export.Manager, maxRange time.Duration, missionEpoch time.Time) *Handlers {
+	return &Handlers{db: db, listener: listener, exports: exports, maxRange: maxRange, missionEpoch: missionEpoch}
 }
 
 func (h *Handlers) GetTelemetry(c *fiber.Ctx) error {
@@ -24,9 +34,26 @@ func (h *Handlers) GetTelemetry(c *fiber.Ctx) error {
 		})
 	}
 
-	if query.StartTime.IsZero() || query.EndTime.IsZero() {
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "start_time and end_time are required",
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
@@ -37,48 +64,179 @@ func (h *Handlers) GetTelemetry(c *fiber.Ctx) error {
 		query.PageSize = 20
 	}
 
-	if query.SubsystemID == nil {
-		defaultID := uint16(1)
-		query.SubsystemID = &defaultID
+	if err := parsePaging(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseValuePredicates(c, query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	// Predicate values are given in the requested units.
+	units.CanonicalPredicates(query.Predicates)
+
+	if query.Fields != "" {
+		fields, err := parseParameters(query.Fields)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		query.Projection = fields
+	}
+
+	switch query.Format {
+	case "", "raw":
+	case "chart":
+		if !times.IsUTC() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "format 'chart' is only available in UTC",
+			})
+		}
+		return h.getTelemetryChart(c, query, units)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be 'raw' or 'chart'",
+		})
 	}
 
-	records, totalCount, err := h.db.GetTelemetry(query)
+	records, page, err := h.db.GetTelemetry(query)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch telemetry data",
 		})
 	}
+	for i := range records {
+		units.ConvertRecord(&records[i])
+	}
 
 	response := models.TelemetryResponse{
-		Data: records,
-		Metadata: struct {
-			TotalCount int              `json:"total_count"`
-			PageCount  int              `json:"page_count"`
-			HasMore    bool             `json:"has_more"`
-			TimeRange  models.TimeRange `json:"time_range"`
-		}{
-			TotalCount: totalCount,
-			PageCount:  (totalCount + query.PageSize - 1) / query.PageSize,
-			HasMore:    totalCount > query.Page*query.PageSize,
-			TimeRange: models.TimeRange{
-				Start: query.StartTime,
-				End:   query.EndTime,
-			},
-		},
+		Data:     projectRecords(records, query.Projection, times),
+		Metadata: pageMetadata(query, page),
 	}
+	response.Metadata.TimeSystem = string(times.System)
+	response.Metadata.Units = units.Symbols()
 
 	return c.JSON(response)
 }
 
+// GetCurrentTelemetry returns the latest record of every subsystem, or of the
+// subsystems given by subsystem_id.
 func (h *Handlers) GetCurrentTelemetry(c *fiber.Ctx) error {
-	record, err := h.db.GetCurrentTelemetry()
+	query := &models.TelemetryQuery{}
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	times, err := h.timeSystem(c, query.TimeSystem, nil)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := h.db.GetCurrentTelemetry(query.SubsystemIDs)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch current telemetry",
 		})
 	}
+	for i := range records {
+		units.ConvertRecord(&records[i])
+	}
+
+	// The response is a bare array, so the units go in a header.
+	c.Set("X-Telemetry-Units", unitsHeader(units))
+	return c.JSON(projectRecords(records, nil, times))
+}
+
+// GetTelemetryAt returns, for each subsystem, the last known value of every
+// parameter at or before the given time and how old each value was then.
+func (h *Handlers) GetTelemetryAt(c *fiber.Ctx) error {
+	query := new(models.SnapshotQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"time": &query.Time,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.Time.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "time is required",
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var maxAge time.Duration
+	if query.MaxAge != "" {
+		maxAge, err = time.ParseDuration(query.MaxAge)
+		if err != nil || maxAge <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "max_age must be a positive duration such as '1h'",
+			})
+		}
+	}
+
+	records, err := h.db.GetTelemetryAt(query.Time, query.SubsystemIDs, maxAge)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry snapshot",
+		})
+	}
+
+	snapshots := make([]models.Snapshot, 0, len(records))
+	for _, record := range records {
+		units.ConvertRecord(&record)
+		snapshots = append(snapshots, models.NewSnapshot(record, query.Time))
+	}
 
-	return c.JSON(record)
+	return c.JSON(fiber.Map{
+		"data":        snapshotTimes(snapshots, times),
+		"units":       units.Symbols(),
+		"time_system": times.System,
+	})
+}
+
+func (h *Handlers) GetSubsystems(c *fiber.Ctx) error {
+	subsystems, err := h.db.GetSubsystems()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch subsystems",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": subsystems,
+	})
 }
 
 func (h *Handlers) GetAnomalies(c *fiber.Ctx) error {
@@ -90,9 +248,26 @@ func (h *Handlers) GetAnomalies(c *fiber.Ctx) error {
 		})
 	}
 
-	if query.StartTime.IsZero() || query.EndTime.IsZero() {
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "start_time and end_time are required",
+			"error": err.Error(),
 		})
 	}
 
@@ -103,35 +278,28 @@ func (h *Handlers) GetAnomalies(c *fiber.Ctx) error {
 		query.PageSize = 20
 	}
 
-	if query.SubsystemID == nil {
-		defaultID := uint16(1)
-		query.SubsystemID = &defaultID
+	if err := parsePaging(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	anomalies, totalCount, err := h.db.GetAnomalies(query)
+	anomalies, page, err := h.db.GetAnomalies(query)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch anomalies",
 		})
 	}
+	for i := range anomalies {
+		units.ConvertAnomaly(&anomalies[i])
+	}
 
 	response := models.TelemetryResponse{
-		Data: anomalies,
-		Metadata: struct {
-			TotalCount int              `json:"total_count"`
-			PageCount  int              `json:"page_count"`
-			HasMore    bool             `json:"has_more"`
-			TimeRange  models.TimeRange `json:"time_range"`
-		}{
-			TotalCount: totalCount,
-			PageCount:  (totalCount + query.PageSize - 1) / query.PageSize,
-			HasMore:    totalCount > query.Page*query.PageSize,
-			TimeRange: models.TimeRange{
-				Start: query.StartTime,
-				End:   query.EndTime,
-			},
-		},
+		Data:     anomalyTimes(anomalies, times),
+		Metadata: pageMetadata(query, page),
 	}
+	response.Metadata.TimeSystem = string(times.System)
+	response.Metadata.Units = units.Symbols()
 
 	return c.JSON(response)
 }
@@ -145,18 +313,85 @@ func (h *Handlers) GetAggregates(c *fiber.Ctx) error {
 		})
 	}
 
-	metrics, err := h.db.GetAggregatedTelemetry(query)
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 500
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = models.IntervalFor(query.EndTime.Sub(query.StartTime), query.MaxPoints)
+	}
+	if _, ok := models.AggregationIntervals[query.GroupBy]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported group_by interval %q", query.GroupBy),
+		})
+	}
+
+	parameters, err := parseParameters(query.Parameters)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	functions := splitList(query.Aggregation)
+	if len(functions) == 0 {
+		functions = []string{"min", "max", "avg", "count"}
+	}
+	for _, function := range functions {
+		if !models.IsAggregation(function) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Unsupported aggregation %q", function),
+			})
+		}
+	}
+
+	metrics, resolution, err := h.db.GetAggregatedTelemetry(query, parameters, functions)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch aggregated telemetry",
 		})
 	}
+	for _, metric := range metrics {
+		convertStatistics(metric.Values, units)
+	}
 
-	return c.JSON(fiber.Map{
-		"data": metrics,
-		"time_range": models.TimeRange{
-			Start: query.StartTime,
-			End:   query.EndTime,
+	response := models.TelemetryResponse{
+		Data: aggregateTimes(metrics, times),
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(metrics),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			GroupBy:    query.GroupBy,
+			Resolution: resolution,
+			TimeSystem: string(times.System),
+			Units:      units.Symbols(),
 		},
-	})
+	}
+
+	return c.JSON(response)
 }
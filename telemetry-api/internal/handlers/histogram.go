@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"telemetry-api/internal/database"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultHistogramBins = 20
+	maxHistogramBins     = 1000
+)
+
+// GetHistogram returns the distribution of a parameter per subsystem over a
+// time range, with each bin annotated by the parameter's limit bands.
+func (h *Handlers) GetHistogram(c *fiber.Ctx) error {
+	query := new(models.HistogramQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !models.IsParameter(query.Parameter) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "parameter must be one of the telemetry parameters",
+		})
+	}
+	if query.BinWidth < 0 || query.Bins < 0 || math.IsNaN(query.BinWidth) || math.IsInf(query.BinWidth, 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "bins and bin_width must be positive",
+		})
+	}
+	if query.Min != nil && query.Max != nil && *query.Min >= *query.Max {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "min must be below max",
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	filter := &models.TelemetryQuery{
+		StartTime:    query.StartTime,
+		EndTime:      query.EndTime,
+		SubsystemIDs: query.SubsystemIDs,
+	}
+
+	low, high, ok := 0.0, 0.0, true
+	if query.Min == nil || query.Max == nil {
+		low, high, ok, err = h.db.GetValueRange(filter, query.Parameter)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch telemetry range",
+			})
+		}
+	}
+
+	histograms := []models.Histogram{}
+	if ok {
+		// Bins are laid out in the requested units; the query bins the stored
+		// values between the same edges converted back.
+		edges, err := histogramEdges(query, units.Value(query.Parameter, low), units.Value(query.Parameter, high))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		bins := len(edges) - 1
+		binLow := units.Canonical(query.Parameter, edges[0])
+		binHigh := units.Canonical(query.Parameter, edges[bins])
+		// Keep observed extremes inside despite rounding in the conversion.
+		if query.Min == nil && binLow > low {
+			binLow = low
+		}
+		if query.Max == nil && binHigh <= high {
+			binHigh = math.Nextafter(high, math.Inf(1))
+		}
+
+		counts, err := h.db.GetHistogram(filter, query.Parameter, binLow, binHigh, bins)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch histogram",
+			})
+		}
+
+		subsystems := make([]uint16, 0, len(counts))
+		for id := range counts {
+			subsystems = append(subsystems, id)
+		}
+		sort.Slice(subsystems, func(i, j int) bool { return subsystems[i] < subsystems[j] })
+
+		for _, id := range subsystems {
+			histograms = append(histograms, histogram(id, query.Parameter, units.Limits(query.Parameter), edges, counts[id]))
+		}
+	}
+
+	response := models.TelemetryResponse{
+		Data: histograms,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(histograms),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			Resolution: database.RawResolution,
+			Units:      units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// histogramEdges returns the bin edges for the query. Bounds that were not
+// given come from the observed range; a defaulted upper bound is widened so
+// the largest value falls in the last bin rather than the overflow.
+func histogramEdges(query *models.HistogramQuery, observedLow, observedHigh float64) ([]float64, error) {
+	low, high := observedLow, observedHigh
+	if query.Min != nil {
+		low = *query.Min
+	}
+	if query.Max != nil {
+		high = *query.Max
+	}
+	if math.IsNaN(low) || math.IsInf(low, 0) || math.IsNaN(high) || math.IsInf(high, 0) {
+		return nil, fmt.Errorf("min and max must be finite")
+	}
+	if high < low {
+		return nil, fmt.Errorf("no values fall between min and max")
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	var bins int
+	var width float64
+	if query.BinWidth > 0 {
+		width = query.BinWidth
+		// Check the count as a float: a tiny width overflows int.
+		span := (high - low) / width
+		if query.Max == nil {
+			span = math.Floor(span) + 1
+		} else {
+			span = math.Ceil(span)
+		}
+		if math.IsNaN(span) || math.IsInf(span, 0) || span > maxHistogramBins {
+			return nil, fmt.Errorf("histogram would have more than %d bins; use a wider bin_width", maxHistogramBins)
+		}
+		bins = int(span)
+	} else {
+		bins = query.Bins
+		if bins == 0 {
+			bins = defaultHistogramBins
+		}
+		if query.Max == nil {
+			high = math.Nextafter(high, math.Inf(1))
+		}
+		width = (high - low) / float64(bins)
+	}
+	if bins > maxHistogramBins {
+		return nil, fmt.Errorf("histogram would have more than %d bins; use a wider bin_width", maxHistogramBins)
+	}
+
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = low + float64(i)*width
+	}
+	if query.BinWidth == 0 {
+		edges[bins] = high
+	}
+	return edges, nil
+}
+
+// histogram builds one subsystem's histogram from width_bucket counts, which
+// hold the underflow at index 0 and the overflow at index len(edges).
+func histogram(subsystemID uint16, parameter string, limits models.Limits, edges []float64, counts []int) models.Histogram {
+	bins := len(edges) - 1
+
+	result := models.Histogram{
+		SubsystemID: subsystemID,
+		Parameter:   parameter,
+		Underflow:   counts[0],
+		Overflow:    counts[bins+1],
+		Bins:        make([]models.HistogramBin, bins),
+		Limits:      limits.Markers(),
+	}
+	for i := range result.Bins {
+		bin := models.HistogramBin{Lower: edges[i], Upper: edges[i+1], Count: counts[i+1]}
+		limits.Annotate(&bin)
+		result.Bins[i] = bin
+	}
+	for _, count := range counts {
+		result.Count += count
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"telemetry-api/internal/models"
+)
+
+func TestHistogramEdges(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		query     models.HistogramQuery
+		low, high float64
+		bins      int
+		first     float64
+		last      float64
+		wantErr   bool
+	}{
+		{name: "default bin count", low: 18, high: 36, bins: defaultHistogramBins, first: 18, last: math.Nextafter(36, math.Inf(1))},
+		{name: "bin count with max", query: models.HistogramQuery{Bins: 4, Max: float(40)}, low: 20, high: 36, bins: 4, first: 20, last: 40},
+		{name: "bin width includes observed max", query: models.HistogramQuery{BinWidth: 2.5}, low: 18, high: 36, bins: 8, first: 18, last: 38},
+		{name: "bin width with max rounds up", query: models.HistogramQuery{BinWidth: 3, Min: float(0), Max: float(10)}, bins: 4, first: 0, last: 12},
+		{name: "constant values", low: 5, high: 5, bins: defaultHistogramBins, first: 5, last: 6},
+		{name: "too many bins", query: models.HistogramQuery{BinWidth: 0.001}, low: 0, high: 100, wantErr: true},
+		{name: "tiny bin width overflows int", query: models.HistogramQuery{BinWidth: 1e-300}, low: 0, high: 100, wantErr: true},
+		{name: "bin count over limit", query: models.HistogramQuery{Bins: maxHistogramBins + 1}, low: 0, high: 1, wantErr: true},
+		{name: "infinite max", query: models.HistogramQuery{Max: float(math.Inf(1))}, low: 0, high: 1, wantErr: true},
+		{name: "observed range below min", query: models.HistogramQuery{Min: float(50)}, low: 0, high: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edges, err := histogramEdges(&tt.query, tt.low, tt.high)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d edges", len(edges))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(edges) - 1; got != tt.bins {
+				t.Errorf("bins = %d, want %d", got, tt.bins)
+			}
+			if edges[0] != tt.first || math.Abs(edges[len(edges)-1]-tt.last) > 1e-9 {
+				t.Errorf("edges span [%v, %v], want [%v, %v]", edges[0], edges[len(edges)-1], tt.first, tt.last)
+			}
+			for i := 1; i < len(edges); i++ {
+				if edges[i] <= edges[i-1] {
+					t.Fatalf("edges not increasing at %d: %v", i, edges)
+				}
+			}
+		})
+	}
+}
+
+func TestHistogramAnnotations(t *testing.T) {
+	edges := []float64{15, 20, 25, 30, 35, 40}
+	counts := []int{1, 2, 3, 4, 5, 6, 7}
+
+	h := histogram(1, "temperature", models.ParameterLimits["temperature"], edges, counts)
+
+	if h.Underflow != 1 || h.Overflow != 7 || h.Count != 28 {
+		t.Errorf("underflow/overflow/count = %d/%d/%d, want 1/7/28", h.Underflow, h.Overflow, h.Count)
+	}
+	bands := []string{models.SeverityWarning, models.SeverityNormal, models.SeverityNormal, models.SeverityWarning, models.SeverityCritical}
+	for i, bin := range h.Bins {
+		if bin.Band != bands[i] {
+			t.Errorf("bin %d [%v, %v) band = %s, want %s", i, bin.Lower, bin.Upper, bin.Band, bands[i])
+		}
+	}
+
+	h = histogram(1, "temperature", models.ParameterLimits["temperature"], []float64{18, 22}, []int{0, 1, 0})
+	if h.Bins[0].Band != models.BandMixed || len(h.Bins[0].Limits) != 1 || h.Bins[0].Limits[0] != "normal_min" {
+		t.Errorf("bin straddling normal_min = %+v", h.Bins[0])
+	}
+}
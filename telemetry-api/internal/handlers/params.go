@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/timesys"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// splitList parses a comma-separated query value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseParameters validates a comma-separated parameter list, returning all
+// parameters when the value is empty.
+func parseParameters(value string) ([]string, error) {
+	parameters := splitList(value)
+	if len(parameters) == 0 {
+		return models.Parameters, nil
+	}
+	for _, p := range parameters {
+		if !models.IsParameter(p) {
+			return nil, fmt.Errorf("unknown parameter %q", p)
+		}
+	}
+	return parameters, nil
+}
+
+// parsePaging decodes the cursor and settles the total mode. Keyset pages
+// skip the count unless one is asked for, since counting is what makes deep
+// offset pages slow.
+func parsePaging(query *models.TelemetryQuery) error {
+	if query.Cursor != "" {
+		cursor, err := models.DecodeCursor(query.Cursor)
+		if err != nil {
+			return err
+		}
+		query.After = cursor
+	}
+
+	switch query.Total {
+	case "":
+		query.Total = models.TotalExact
+		if query.After != nil {
+			query.Total = models.TotalNone
+		}
+	case models.TotalExact, models.TotalEstimate, models.TotalNone:
+	default:
+		return fmt.Errorf("total must be 'exact', 'estimate' or 'none'")
+	}
+	return nil
+}
+
+func pageMetadata(query *models.TelemetryQuery, page models.PageInfo) models.ResponseMetadata {
+	metadata := models.ResponseMetadata{
+		TotalCount:     page.TotalCount,
+		TotalEstimated: page.TotalEstimated,
+		HasMore:        page.HasMore,
+		NextCursor:     page.NextCursor,
+		TimeRange: models.TimeRange{
+			Start: query.StartTime,
+			End:   query.EndTime,
+		},
+	}
+	if query.Total != models.TotalNone {
+		metadata.PageCount = (page.TotalCount + query.PageSize - 1) / query.PageSize
+	}
+	return metadata
+}
+
+// parseValuePredicates collects <parameter>_<op> filters such as battery_lt=50,
+// which the query parser cannot bind to struct fields.
+func parseValuePredicates(c *fiber.Ctx, query *models.TelemetryQuery) error {
+	queries := c.Queries()
+
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		predicate, ok, err := models.ParseValuePredicate(key, queries[key])
+		if err != nil {
+			return err
+		}
+		if ok {
+			query.Predicates = append(query.Predicates, predicate)
+		}
+	}
+	return nil
+}
+
+// projectRecords trims records down to the requested parameters and renders
+// their timestamps in the requested time system. When neither was asked for
+// the records are returned whole, with their timestamps normalized to UTC.
+func projectRecords(records []models.TelemetryRecord, parameters []string, times timesys.Converter) interface{} {
+	if len(parameters) == 0 {
+		if times.IsUTC() {
+			for i := range records {
+				records[i].Timestamp = records[i].Timestamp.UTC()
+			}
+			return records
+		}
+		parameters = models.Parameters
+	}
+
+	projected := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		projected[i] = record.Project(parameters)
+		projected[i]["timestamp"] = times.Format(record.Timestamp)
+	}
+	return projected
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"time"
+
+	"telemetry-api/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PlaybackWindow reads start_time and end_time of a playback upgrade request
+// like any other time parameters and hands them to realtime.Playback through
+// Locals, so a bad window is rejected before the upgrade.
+func (h *Handlers) PlaybackWindow(c *fiber.Ctx) error {
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var start, end time.Time
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &start,
+		"end_time":   &end,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if start.IsZero() || end.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "start_time and end_time are required",
+		})
+	}
+
+	c.Locals(realtime.PlaybackStartLocal, start)
+	c.Locals(realtime.PlaybackEndLocal, end)
+	return c.Next()
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSeriesPoints caps the buckets per series, since gap filling emits a
+// point for every bucket whether or not it has data.
+const maxSeriesPoints = 10000
+
+// GetSeries returns fixed-interval series with gaps filled by the requested
+// strategy (null, locf or interpolate), for charts that must not draw across
+// dropouts.
+func (h *Handlers) GetSeries(c *fiber.Ctx) error {
+	query := new(models.SeriesQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 500
+	}
+	if query.Interval == "" {
+		query.Interval = models.IntervalFor(query.EndTime.Sub(query.StartTime), query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.Interval]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported interval %q", query.Interval),
+		})
+	}
+	if query.EndTime.Sub(query.StartTime)/interval > maxSeriesPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("interval %s gives more than %d points; use a wider interval", query.Interval, maxSeriesPoints),
+		})
+	}
+
+	switch query.Fill {
+	case "":
+		query.Fill = models.FillNull
+	case models.FillNull, models.FillLOCF, models.FillInterpolate:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "fill must be 'null', 'locf' or 'interpolate'",
+		})
+	}
+
+	parameters, err := parseParameters(query.Parameters)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	series, resolution, err := h.db.GetSeries(query, parameters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry series",
+		})
+	}
+	for _, s := range series {
+		for _, point := range s.Points {
+			convertValues(point.Values, units)
+		}
+	}
+
+	response := models.TelemetryResponse{
+		Data: seriesTimes(series, times),
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(series),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			GroupBy:    query.Interval,
+			Resolution: resolution,
+			Fill:       query.Fill,
+			TimeSystem: string(times.System),
+			Units:      units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
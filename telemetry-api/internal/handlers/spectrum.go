@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"telemetry-api/internal/analysis"
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// minSpectrumSamples is the shortest series worth transforming.
+const minSpectrumSamples = 16
+
+// GetSpectrum resamples a parameter to a regular interval, interpolating
+// across gaps, and returns its power spectral density and dominant
+// frequencies, e.g. to follow the orbital and eclipse cycles.
+func (h *Handlers) GetSpectrum(c *fiber.Ctx) error {
+	query := new(models.SpectrumQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !models.IsParameter(query.Parameter) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "parameter must be one of the telemetry parameters",
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if query.Peaks <= 0 {
+		query.Peaks = 5
+	}
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 2000
+	}
+	if query.Interval == "" {
+		query.Interval = models.IntervalFor(query.EndTime.Sub(query.StartTime), query.MaxPoints)
+	}
+	interval, ok := models.AggregationIntervals[query.Interval]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported interval %q", query.Interval),
+		})
+	}
+	if query.EndTime.Sub(query.StartTime)/interval > maxSeriesPoints {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("interval %s gives more than %d points; use a wider interval", query.Interval, maxSeriesPoints),
+		})
+	}
+
+	series, resolution, err := h.db.GetSeries(&models.SeriesQuery{
+		StartTime:    query.StartTime,
+		EndTime:      query.EndTime,
+		Interval:     query.Interval,
+		Fill:         models.FillInterpolate,
+		SubsystemIDs: query.SubsystemIDs,
+	}, []string{query.Parameter})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry for spectrum",
+		})
+	}
+
+	spectra := make([]models.Spectrum, 0, len(series))
+	for _, s := range series {
+		for _, point := range s.Points {
+			convertValues(point.Values, units)
+		}
+		if spectrum, ok := spectrum(s, query.Parameter, interval, query.Peaks); ok {
+			spectra = append(spectra, spectrum)
+		}
+	}
+
+	response := models.TelemetryResponse{
+		Data: spectra,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(spectra),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			GroupBy:    query.Interval,
+			Resolution: resolution,
+			Fill:       models.FillInterpolate,
+			Units:      units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
+
+// spectrum transforms the interpolated series, dropping the leading and
+// trailing buckets that interpolation cannot fill. ok is false when too few
+// samples remain.
+func spectrum(series models.Series, parameter string, interval time.Duration, peaks int) (models.Spectrum, bool) {
+	first, last := -1, -1
+	for i, point := range series.Points {
+		if point.Values[parameter] != nil {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 || last-first+1 < minSpectrumSamples {
+		return models.Spectrum{}, false
+	}
+
+	// Interpolation fills every interior bucket; hold the last value should
+	// one still be missing.
+	values := make([]float64, 0, last-first+1)
+	for _, point := range series.Points[first : last+1] {
+		if v := point.Values[parameter]; v != nil {
+			values = append(values, *v)
+		} else {
+			values = append(values, values[len(values)-1])
+		}
+	}
+
+	freqs, psd := analysis.Periodogram(values, 1/interval.Seconds())
+
+	result := models.Spectrum{
+		SubsystemID:           series.SubsystemID,
+		Parameter:             parameter,
+		Samples:               len(values),
+		SampleIntervalSeconds: interval.Seconds(),
+		PSD:                   make([]models.SpectrumBin, len(psd)),
+	}
+	for k := range psd {
+		result.PSD[k] = spectrumBin(freqs[k], psd[k])
+	}
+	for _, k := range analysis.Peaks(psd, peaks) {
+		result.Peaks = append(result.Peaks, result.PSD[k])
+	}
+	return result, true
+}
+
+func spectrumBin(frequency, power float64) models.SpectrumBin {
+	bin := models.SpectrumBin{FrequencyHz: frequency, Power: power}
+	if frequency > 0 {
+		bin.PeriodSeconds = floatPtr(1 / frequency)
+	}
+	if math.IsNaN(bin.Power) {
+		bin.Power = 0
+	}
+	return bin
+}
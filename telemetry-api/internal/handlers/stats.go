@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetStats returns summary statistics for each parameter and subsystem over a
+// time range. The default statistics need raw rows; asking only for min, max,
+// mean, stddev and count over an aligned range lets a continuous aggregate
+// serve the request, which metadata.resolution reports.
+func (h *Handlers) GetStats(c *fiber.Ctx) error {
+	query := new(models.StatsQuery)
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	times, err := h.timeSystem(c, query.TimeSystem, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkTimeRange(query.StartTime, query.EndTime, h.maxRange); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	parameters, err := parseParameters(query.Parameters)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	stats := splitList(query.Stats)
+	if len(stats) == 0 {
+		stats = models.StatFunctions
+	}
+	for _, stat := range stats {
+		if !models.IsStat(stat) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Unsupported statistic %q", stat),
+			})
+		}
+	}
+
+	results, resolution, err := h.db.GetStats(query, parameters, stats)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch telemetry stats",
+		})
+	}
+	for _, result := range results {
+		convertStatistics(result.Values, units)
+	}
+
+	response := models.TelemetryResponse{
+		Data: results,
+		Metadata: models.ResponseMetadata{
+			TotalCount: len(results),
+			PageCount:  1,
+			TimeRange: models.TimeRange{
+				Start: query.StartTime,
+				End:   query.EndTime,
+			},
+			Resolution: resolution,
+			TimeSystem: string(times.System),
+			Units:      units.Symbols(),
+		},
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	streamBackfillBatch = 1000
+	streamKeepalive     = 15 * time.Second
+	streamRetry         = 3 * time.Second
+	// streamResumeOverlap is how far before the cursor a replay starts. Ids
+	// and created_at are assigned when an insert starts, so a row can become
+	// visible after rows stored later than it; replays pick such rows up as
+	// long as they commit within the overlap.
+	streamResumeOverlap = 10 * time.Second
+)
+
+func (h *Handlers) StreamTelemetry(c *fiber.Ctx) error {
+	return h.stream(c, realtime.EventTelemetry)
+}
+
+func (h *Handlers) StreamAnomalies(c *fiber.Ctx) error {
+	return h.stream(c, realtime.EventAnomaly)
+}
+
+// stream serves Server-Sent Events for one event type. Event ids are cursors
+// in the order records were stored. Clients resume with the Last-Event-ID
+// header (or last_event_id query parameter), or ask for history from a
+// spacecraft start_time; missed records are replayed from the database before
+// the live feed takes over, and again whenever the stream falls behind the
+// feed. Replays reach streamResumeOverlap back from the cursor, so a resumed
+// stream may repeat events stored just before the client's last one; clients
+// drop records whose id they have already seen.
+// Telemetry streams take the fields, has_anomaly and value filters of
+// GetTelemetry; anomaly streams take fields, anomaly_type and min_severity.
+func (h *Handlers) stream(c *fiber.Ctx, eventType string) error {
+	query := &models.TelemetryQuery{}
+
+	if err := c.QueryParser(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+
+	if err := requireUTC(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := parseTimes(c, map[string]*time.Time{
+		"start_time": &query.StartTime,
+		"end_time":   &query.EndTime,
+	}); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	resume, err := lastEventID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Last-Event-ID",
+		})
+	}
+
+	filter, err := streamFilter(c, query, eventType)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Without a resume point or start_time a stream starts live; a replay
+	// after falling behind then reaches back no further than the time the
+	// connection was received.
+	var cursor models.Cursor
+	var floor time.Time
+	backfill := resume != nil || !query.StartTime.IsZero()
+	if resume != nil {
+		cursor = *resume
+	} else if !backfill {
+		floor = time.Now()
+		cursor.Timestamp = floor
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// Subscribe before replaying so nothing published during the
+		// backfill is lost; duplicates are dropped by id in send.
+		sub, unsubscribe := h.listener.Subscribe(256)
+		defer unsubscribe()
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		// sent holds the ids stored within the overlap before the cursor
+		// that have been sent, since a replay returns them again.
+		sent := make(map[int64]time.Time)
+		if resume != nil {
+			sent[resume.ID] = resume.Timestamp
+		}
+
+		send := func(event realtime.Event) error {
+			at := event.Cursor()
+			if _, ok := sent[at.ID]; ok {
+				return nil
+			}
+			sent[at.ID] = at.Timestamp
+			if cursorAfter(at, cursor) {
+				cursor = at
+			}
+			if !filter.Matches(event) {
+				return nil
+			}
+			return writeSSE(w, at.Encode(), eventType, filter.Payload(event))
+		}
+
+		prune := func() {
+			horizon := cursor.Timestamp.Add(-streamResumeOverlap)
+			for id, at := range sent {
+				if at.Before(horizon) {
+					delete(sent, id)
+				}
+			}
+		}
+
+		replay := func() bool {
+			var from models.Cursor
+			if !cursor.Timestamp.IsZero() {
+				from.Timestamp = cursor.Timestamp.Add(-streamResumeOverlap)
+			}
+			if from.Timestamp.Before(floor) {
+				from.Timestamp = floor
+			}
+			for {
+				batch, err := h.backfill(eventType, from, query.StartTime, query.SubsystemIDs)
+				if err != nil {
+					log.Printf("Error replaying %s stream: %v", eventType, err)
+					writeSSE(w, "", "error", fiber.Map{"error": "Failed to replay missed events"})
+					return false
+				}
+				for _, event := range batch {
+					from = event.Cursor()
+					if err := send(event); err != nil {
+						return false
+					}
+				}
+				if len(batch) < streamBackfillBatch {
+					prune()
+					return true
+				}
+			}
+		}
+
+		if backfill && !replay() {
+			return
+		}
+
+		ticker := time.NewTicker(streamKeepalive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sub.Lagged:
+				// Events were dropped while the client was slow; the
+				// database has them all.
+				if !replay() {
+					return
+				}
+			case event, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				if event.Type != eventType {
+					continue
+				}
+				if err := send(event); err != nil {
+					return
+				}
+			case <-ticker.C:
+				prune()
+				fmt.Fprint(w, ": keepalive\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// streamFilter builds the subscription filter for a stream from its query.
+func streamFilter(c *fiber.Ctx, query *models.TelemetryQuery, eventType string) (realtime.Filter, error) {
+	filter := realtime.Filter{
+		Events:     []string{eventType},
+		Subsystems: query.SubsystemIDs,
+		Units:      query.Units,
+	}
+
+	fields := query.Fields
+	if fields == "" {
+		fields = c.Query("parameters")
+	}
+	if fields != "" {
+		parameters, err := parseParameters(fields)
+		if err != nil {
+			return filter, err
+		}
+		filter.Parameters = parameters
+	}
+
+	if err := parseValuePredicates(c, query); err != nil {
+		return filter, err
+	}
+
+	if eventType == realtime.EventTelemetry {
+		filter.HasAnomaly = query.HasAnomaly
+		filter.Predicates = query.Predicates
+	} else {
+		if query.HasAnomaly != nil || len(query.Predicates) > 0 {
+			return filter, fmt.Errorf("has_anomaly and value filters apply to telemetry streams only")
+		}
+		filter.AnomalyTypes = splitList(c.Query("anomaly_type"))
+		filter.MinSeverity = c.Query("min_severity")
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+
+	// Predicate values are given in the requested units.
+	units, err := models.ParseUnits(query.Units)
+	if err != nil {
+		return filter, err
+	}
+	units.CanonicalPredicates(filter.Predicates)
+	return filter, nil
+}
+
+func (h *Handlers) backfill(eventType string, after models.Cursor, since time.Time, subsystemIDs []uint16) ([]realtime.Event, error) {
+	var events []realtime.Event
+
+	if eventType == realtime.EventAnomaly {
+		records, err := h.db.GetAnomaliesAfter(after, since, subsystemIDs, streamBackfillBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range records {
+			events = append(events, realtime.Event{Type: eventType, Anomaly: &records[i]})
+		}
+		return events, nil
+	}
+
+	records, err := h.db.GetTelemetryAfter(after, since, subsystemIDs, streamBackfillBatch)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		events = append(events, realtime.Event{Type: eventType, Telemetry: &records[i]})
+	}
+	return events, nil
+}
+
+func lastEventID(c *fiber.Ctx) (*models.Cursor, error) {
+	value := c.Get("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return models.DecodeCursor(value)
+}
+
+// cursorAfter reports whether a comes after b in (created_at, id) order.
+func cursorAfter(a, b models.Cursor) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return a.ID > b.ID
+}
+
+func writeSSE(w *bufio.Writer, id string, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return w.Flush()
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/timesys"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseTimes reads the named time parameters of the request into times. Time
+// fields are tagged query:"-" and read here instead of by the query parser,
+// so that a bad value is reported by name.
+//
+// Time parameters accept RFC 3339 timestamps (or the same without a zone,
+// taken as UTC), Unix epoch seconds and expressions relative to the current
+// time such as now, now-6h or now-1d/d. A + in a query string decodes to a
+// space, so now+2h must be sent as now%2B2h.
+func parseTimes(c *fiber.Ctx, times map[string]*time.Time) error {
+	keys := make([]string, 0, len(times))
+	for key := range times {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	for _, key := range keys {
+		t, err := parseTime(c.Query(key), now)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		*times[key] = t
+	}
+	return nil
+}
+
+// parseTime resolves a time parameter against now. Relative expressions are
+// now followed by any number of offsets (+2h, -1d) and an optional rounding
+// to the start of a unit (/d). Units are s, m, h, d, w, M (months) and y;
+// days and larger are calendar units in UTC.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if strings.HasPrefix(value, "now") {
+		if strings.Contains(value, " ") {
+			return time.Time{}, fmt.Errorf("invalid relative time %q; a + must be URL-encoded as %%2B", value)
+		}
+		return parseRelativeTime(value[len("now"):], now.UTC())
+	}
+
+	if isEpoch(value) {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch time %q", value)
+		}
+		whole := int64(seconds)
+		return time.Unix(whole, int64((seconds-float64(whole))*1e9)).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err == nil {
+		return t, nil
+	}
+	// TAI and GPS times are rendered without a zone, so they are read back
+	// the same way.
+	t, err = time.Parse(timesys.Layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q; use RFC 3339, epoch seconds or a relative time such as now-6h", value)
+	}
+	return t, nil
+}
+
+func parseRelativeTime(expr string, now time.Time) (time.Time, error) {
+	t := now
+	for len(expr) > 0 {
+		sign := expr[0]
+		if sign != '+' && sign != '-' {
+			break
+		}
+
+		end := 1
+		for end < len(expr) && expr[end] >= '0' && expr[end] <= '9' {
+			end++
+		}
+		if end == 1 || end == len(expr) {
+			return time.Time{}, fmt.Errorf("invalid offset %q in relative time", expr)
+		}
+		n, err := strconv.Atoi(expr[1:end])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid offset %q in relative time", expr)
+		}
+		if sign == '-' {
+			n = -n
+		}
+
+		t, err = addUnits(t, n, expr[end])
+		if err != nil {
+			return time.Time{}, err
+		}
+		expr = expr[end+1:]
+	}
+
+	if expr == "" {
+		return t, nil
+	}
+	if len(expr) != 2 || expr[0] != '/' {
+		return time.Time{}, fmt.Errorf("invalid relative time suffix %q", expr)
+	}
+	return startOf(t, expr[1])
+}
+
+func addUnits(t time.Time, n int, unit byte) (time.Time, error) {
+	switch unit {
+	case 's':
+		return t.Add(time.Duration(n) * time.Second), nil
+	case 'm':
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case 'h':
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'M':
+		return t.AddDate(0, n, 0), nil
+	case 'y':
+		return t.AddDate(n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown time unit %q; use s, m, h, d, w, M or y", unit)
+}
+
+// startOf truncates t to the start of its unit. Weeks start on Monday.
+func startOf(t time.Time, unit byte) (time.Time, error) {
+	switch unit {
+	case 's':
+		return t.Truncate(time.Second), nil
+	case 'm':
+		return t.Truncate(time.Minute), nil
+	case 'h':
+		return t.Truncate(time.Hour), nil
+	case 'd':
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	case 'w':
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC), nil
+	case 'M':
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	case 'y':
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown time unit %q; use s, m, h, d, w, M or y", unit)
+}
+
+// isEpoch reports whether value is a plain number of seconds, optionally
+// with a fractional part.
+func isEpoch(value string) bool {
+	dot := false
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] >= '0' && value[i] <= '9':
+		case value[i] == '.' && !dot && i > 0:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkTimeRange validates a required start_time/end_time pair. Ranges are
+// half-open, so start_time equal to end_time is an empty range rather than an
+// error. maxRange of zero means the range is unbounded.
+func checkTimeRange(start, end time.Time, maxRange time.Duration) error {
+	if start.IsZero() || end.IsZero() {
+		return fmt.Errorf("start_time and end_time are required")
+	}
+	if end.Before(start) {
+		return fmt.Errorf("start_time (%s) must not be after end_time (%s)", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	if maxRange > 0 && end.Sub(start) > maxRange {
+		return fmt.Errorf("time range of %s exceeds the maximum of %s", end.Sub(start), maxRange)
+	}
+	return nil
+}
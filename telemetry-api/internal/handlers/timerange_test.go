@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, time.March, 13, 15, 4, 5, 600, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "now", want: now},
+		{value: "now-6h", want: now.Add(-6 * time.Hour)},
+		{value: "now+90s", want: now.Add(90 * time.Second)},
+		{value: "now-1d+2h", want: now.AddDate(0, 0, -1).Add(2 * time.Hour)},
+		{value: "now-1M", want: time.Date(2024, time.February, 13, 15, 4, 5, 600, time.UTC)},
+		{value: "now-1y", want: time.Date(2023, time.March, 13, 15, 4, 5, 600, time.UTC)},
+		{value: "now/h", want: time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC)},
+		{value: "now-1d/d", want: time.Date(2024, time.March, 12, 0, 0, 0, 0, time.UTC)},
+		{value: "now/w", want: time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{value: "now/M", want: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "now/y", want: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "1700000000", want: time.Unix(1700000000, 0).UTC()},
+		{value: "1700000000.25", want: time.Unix(1700000000, 250000000).UTC()},
+		{value: "2024-03-13T12:00:00Z", want: time.Date(2024, time.March, 13, 12, 0, 0, 0, time.UTC)},
+		{value: "2024-03-13T12:00:00.5+02:00", want: time.Date(2024, time.March, 13, 10, 0, 0, 500000000, time.UTC)},
+		{value: "2024-03-13T12:00:00.25", want: time.Date(2024, time.March, 13, 12, 0, 0, 250000000, time.UTC)},
+		{value: "now 2h", wantErr: true}, // an unencoded + arrives as a space
+		{value: "now-", wantErr: true},
+		{value: "now-h", wantErr: true},
+		{value: "now-6", wantErr: true},
+		{value: "now-6q", wantErr: true},
+		{value: "now/q", wantErr: true},
+		{value: "now/dd", wantErr: true},
+		{value: ".5", wantErr: true},
+		{value: "1700000000.", want: time.Unix(1700000000, 0).UTC()},
+		{value: "-1700000000", wantErr: true},
+		{value: "2024-03-13", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTime(tt.value, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTimeRange(t *testing.T) {
+	start := time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		maxRange time.Duration
+		wantErr  bool
+	}{
+		{name: "valid", start: start, end: start.Add(time.Hour)},
+		{name: "unbounded", start: start, end: start.AddDate(10, 0, 0)},
+		{name: "at the limit", start: start, end: start.Add(time.Hour), maxRange: time.Hour},
+		{name: "over the limit", start: start, end: start.Add(time.Hour + time.Second), maxRange: time.Hour, wantErr: true},
+		{name: "missing start", end: start, wantErr: true},
+		{name: "missing end", start: start, wantErr: true},
+		{name: "empty range", start: start, end: start},
+		{name: "reversed", start: start.Add(time.Hour), end: start, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTimeRange(tt.start, tt.end, tt.maxRange)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
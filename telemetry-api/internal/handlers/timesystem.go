@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/timesys"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// timeSystem resolves the time_system parameter and reads the named time
+// parameters in that system: RFC 3339 values are TAI or GPS wall-clock
+// readings, and with MET plain numbers are seconds since the mission epoch.
+// Relative times are always from now.
+func (h *Handlers) timeSystem(c *fiber.Ctx, name string, times map[string]*time.Time) (timesys.Converter, error) {
+	system, err := timesys.Parse(name)
+	if err != nil {
+		return timesys.Converter{}, err
+	}
+	converter, err := timesys.NewConverter(system, h.missionEpoch)
+	if err != nil {
+		return timesys.Converter{}, err
+	}
+
+	if err := parseTimes(c, times); err != nil {
+		return timesys.Converter{}, err
+	}
+
+	for key, t := range times {
+		raw := c.Query(key)
+		if raw == "" || strings.HasPrefix(raw, "now") {
+			continue
+		}
+		if !isEpoch(raw) {
+			*t = converter.Unshift(*t)
+			continue
+		}
+		if system == timesys.MET {
+			seconds, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return timesys.Converter{}, err
+			}
+			*t = converter.FromElapsed(seconds)
+		}
+	}
+	return converter, nil
+}
+
+// requireUTC rejects a non-UTC time_system on endpoints whose responses are
+// only rendered in UTC, rather than ignoring it.
+func requireUTC(c *fiber.Ctx) error {
+	system, err := timesys.Parse(c.Query("time_system"))
+	if err != nil {
+		return err
+	}
+	if system != timesys.UTC {
+		return fmt.Errorf("time_system %s is not supported here; times are UTC", system)
+	}
+	return nil
+}
+
+// The *Times helpers render a response's timestamps in the requested time
+// system. In UTC the records are returned as they are, with every timestamp
+// normalized to UTC rather than the database session's zone.
+
+func anomalyTimes(anomalies []models.AnomalyRecord, times timesys.Converter) interface{} {
+	if times.IsUTC() {
+		for i := range anomalies {
+			anomalies[i].Timestamp = anomalies[i].Timestamp.UTC()
+		}
+		return anomalies
+	}
+
+	converted := make([]map[string]interface{}, len(anomalies))
+	for i, a := range anomalies {
+		converted[i] = map[string]interface{}{
+			"id":             a.ID,
+			"timestamp":      times.Format(a.Timestamp),
+			"subsystem_id":   a.SubsystemID,
+			"anomaly_type":   a.AnomalyType,
+			"value":          a.Value,
+			"expected_range": a.ExpectedRange,
+		}
+	}
+	return converted
+}
+
+func aggregateTimes(metrics []models.AggregatedMetric, times timesys.Converter) interface{} {
+	if times.IsUTC() {
+		for i := range metrics {
+			metrics[i].Timestamp = metrics[i].Timestamp.UTC()
+		}
+		return metrics
+	}
+
+	converted := make([]map[string]interface{}, len(metrics))
+	for i, m := range metrics {
+		converted[i] = map[string]interface{}{
+			"timestamp":    times.Format(m.Timestamp),
+			"subsystem_id": m.SubsystemID,
+			"count":        m.Count,
+			"values":       m.Values,
+		}
+	}
+	return converted
+}
+
+func snapshotTimes(snapshots []models.Snapshot, times timesys.Converter) interface{} {
+	if times.IsUTC() {
+		for i := range snapshots {
+			snapshots[i].Time = snapshots[i].Time.UTC()
+			for parameter, v := range snapshots[i].Values {
+				v.Timestamp = v.Timestamp.UTC()
+				snapshots[i].Values[parameter] = v
+			}
+		}
+		return snapshots
+	}
+
+	converted := make([]map[string]interface{}, len(snapshots))
+	for i, s := range snapshots {
+		values := make(map[string]interface{}, len(s.Values))
+		for parameter, v := range s.Values {
+			values[parameter] = map[string]interface{}{
+				"value":       v.Value,
+				"timestamp":   times.Format(v.Timestamp),
+				"age_seconds": v.AgeSeconds,
+			}
+		}
+		converted[i] = map[string]interface{}{
+			"subsystem_id": s.SubsystemID,
+			"time":         times.Format(s.Time),
+			"record_id":    s.RecordID,
+			"has_anomaly":  s.HasAnomaly,
+			"values":       values,
+		}
+	}
+	return converted
+}
+
+func seriesTimes(series []models.Series, times timesys.Converter) interface{} {
+	if times.IsUTC() {
+		for _, s := range series {
+			for j := range s.Points {
+				s.Points[j].Timestamp = s.Points[j].Timestamp.UTC()
+			}
+		}
+		return series
+	}
+
+	converted := make([]map[string]interface{}, len(series))
+	for i, s := range series {
+		points := make([]map[string]interface{}, len(s.Points))
+		for j, p := range s.Points {
+			points[j] = map[string]interface{}{
+				"timestamp": times.Format(p.Timestamp),
+				"count":     p.Count,
+				"values":    p.Values,
+				"quality":   p.Quality,
+			}
+		}
+		converted[i] = map[string]interface{}{
+			"subsystem_id": s.SubsystemID,
+			"points":       points,
+		}
+	}
+	return converted
+}
+
+func summaryTimes(summary models.AnomalySummary, times timesys.Converter) interface{} {
+	if times.IsUTC() {
+		summary.Start, summary.End = summary.Start.UTC(), summary.End.UTC()
+		summary.Previous.Start, summary.Previous.End = summary.Previous.Start.UTC(), summary.Previous.End.UTC()
+		for i := range summary.Buckets {
+			summary.Buckets[i].Timestamp = summary.Buckets[i].Timestamp.UTC()
+		}
+		return summary
+	}
+
+	period := func(p models.AnomalyPeriod) map[string]interface{} {
+		return map[string]interface{}{
+			"start":                     times.Format(p.Start),
+			"end":                       times.Format(p.End),
+			"total":                     p.Total,
+			"by_type":                   p.ByType,
+			"mean_time_between_seconds": p.MeanTimeBetweenSeconds,
+		}
+	}
+
+	buckets := make([]map[string]interface{}, len(summary.Buckets))
+	for i, b := range summary.Buckets {
+		buckets[i] = map[string]interface{}{
+			"timestamp": times.Format(b.Timestamp),
+			"count":     b.Count,
+			"by_type":   b.ByType,
+		}
+	}
+
+	converted := period(summary.AnomalyPeriod)
+	converted["by_subsystem"] = summary.BySubsystem
+	converted["top_parameters"] = summary.TopParameters
+	converted["buckets"] = buckets
+	converted["previous"] = period(summary.Previous)
+	converted["change"] = summary.Change
+	return converted
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"telemetry-api/internal/models"
+	"telemetry-api/internal/timesys"
+)
+
+func TestProjectRecordsUTC(t *testing.T) {
+	// As lib/pq returns timestamptz values in a session zone other than UTC.
+	local := time.Date(2024, time.March, 13, 14, 0, 0, 0, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		name       string
+		parameters []string
+	}{
+		{name: "whole records"},
+		{name: "projected", parameters: []string{"battery"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := []models.TelemetryRecord{{ID: 1, Timestamp: local}}
+
+			var got interface{}
+			switch data := projectRecords(records, tt.parameters, timesys.Converter{System: timesys.UTC}).(type) {
+			case []models.TelemetryRecord:
+				got = data[0].Timestamp
+			case []map[string]interface{}:
+				got = data[0]["timestamp"]
+			}
+
+			ts, ok := got.(time.Time)
+			if !ok {
+				t.Fatalf("got timestamp %v (%T), want a time.Time", got, got)
+			}
+			if ts.Location() != time.UTC || !ts.Equal(local) {
+				t.Errorf("got %s, want %s", ts, local.UTC())
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"telemetry-api/internal/models"
+)
+
+// convertStatistics converts aggregations or statistics keyed by parameter
+// and then by function name, in place.
+func convertStatistics(values map[string]map[string]float64, units models.Units) {
+	for parameter, stats := range values {
+		for name, v := range stats {
+			stats[name] = units.Statistic(parameter, name, v)
+		}
+	}
+}
+
+// convertValues converts optional values keyed by parameter, in place.
+func convertValues(values map[string]*float64, units models.Units) {
+	for parameter, v := range values {
+		if v != nil {
+			values[parameter] = floatPtr(units.Value(parameter, *v))
+		}
+	}
+}
+
+// unitsHeader formats units as parameter=unit pairs sorted by parameter.
+func unitsHeader(units models.Units) string {
+	symbols := units.Symbols()
+	pairs := make([]string, 0, len(symbols))
+	for parameter, symbol := range symbols {
+		pairs = append(pairs, parameter+"="+symbol)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AggregationIntervals whitelists the bucket widths accepted by group_by.
+var AggregationIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// IntervalFor returns the narrowest whitelisted interval that splits span
+// into at most maxPoints buckets, falling back to the widest interval.
+func IntervalFor(span time.Duration, maxPoints int) string {
+	names := make([]string, 0, len(AggregationIntervals))
+	for name := range AggregationIntervals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return AggregationIntervals[names[i]] < AggregationIntervals[names[j]]
+	})
+
+	for _, name := range names {
+		if span/AggregationIntervals[name] <= time.Duration(maxPoints) {
+			return name
+		}
+	}
+	return names[len(names)-1]
+}
+
+var aggregationFunctions = map[string]bool{
+	"min":    true,
+	"max":    true,
+	"avg":    true,
+	"count":  true,
+	"stddev": true,
+	"first":  true,
+	"last":   true,
+}
+
+// IsAggregation reports whether name is a supported aggregation function.
+// Percentiles are written as p1 through p99.
+func IsAggregation(name string) bool {
+	if aggregationFunctions[name] {
+		return true
+	}
+	_, ok := Percentile(name)
+	return ok
+}
+
+// Percentile returns the fraction for a percentile function such as "p95".
+func Percentile(name string) (float64, bool) {
+	if !strings.HasPrefix(name, "p") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(name[1:])
+	if err != nil || n < 1 || n > 99 {
+		return 0, false
+	}
+	return float64(n) / 100, true
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type AnomalySummaryQuery struct {
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	GroupBy      string    `query:"group_by"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints    int       `query:"max_points"`   // target bucket count when group_by is empty
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	TimeSystem   string    `query:"time_system"`  // 'UTC', 'TAI', 'GPS' or 'MET'
+}
+
+// AnomalyCount is one cell of the anomaly count cube read from the database.
+type AnomalyCount struct {
+	Bucket      time.Time
+	SubsystemID uint16
+	AnomalyType string
+	Count       int
+}
+
+// AnomalyPeriod totals the anomalies in one period. MeanTimeBetween is the
+// mean spacing of consecutive anomalies, undefined for fewer than two.
+type AnomalyPeriod struct {
+	Start                  time.Time      `json:"start"`
+	End                    time.Time      `json:"end"`
+	Total                  int            `json:"total"`
+	ByType                 map[string]int `json:"by_type"`
+	MeanTimeBetweenSeconds *float64       `json:"mean_time_between_seconds"`
+}
+
+type AnomalySummary struct {
+	AnomalyPeriod
+	BySubsystem   []SubsystemCount `json:"by_subsystem"`
+	TopParameters []ParameterCount `json:"top_parameters"`
+	Buckets       []AnomalyBucket  `json:"buckets"`
+	Previous      AnomalyPeriod    `json:"previous"`
+	Change        AnomalyChange    `json:"change"`
+}
+
+type SubsystemCount struct {
+	SubsystemID uint16 `json:"subsystem_id"`
+	Count       int    `json:"count"`
+}
+
+type ParameterCount struct {
+	Parameter string `json:"parameter"`
+	Count     int    `json:"count"`
+}
+
+// AnomalyBucket counts the anomalies in one group_by interval. Every bucket
+// in the range is present, including empty ones.
+type AnomalyBucket struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Count     int            `json:"count"`
+	ByType    map[string]int `json:"by_type"`
+}
+
+// AnomalyChange compares the period with the one before it, as percentages.
+// A change from zero is undefined and left out.
+type AnomalyChange struct {
+	TotalPct  *float64           `json:"total_pct"`
+	ByTypePct map[string]float64 `json:"by_type_pct"`
+}
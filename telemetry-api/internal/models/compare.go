@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type CompareQuery struct {
+	StartTime         time.Time `query:"-"`            // start_time of the current window, read by parseTimes
+	EndTime           time.Time `query:"-"`            // end_time of the current window, read by parseTimes
+	BaselineStartTime time.Time `query:"-"`            // baseline_start_time of a window of the same length, read by parseTimes
+	Offset            string    `query:"offset"`       // or: baseline starts this long before start_time, e.g. '24h'
+	Interval          string    `query:"interval"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints         int       `query:"max_points"`   // target pair count when interval is empty
+	Parameters        string    `query:"parameters"`   // comma-separated, defaults to all
+	SubsystemIDs      []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	Units             string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// RelativeBucket holds parameter averages for one bucket of a window,
+// numbered from the start of the window.
+type RelativeBucket struct {
+	SubsystemID uint16
+	Index       int
+	Values      map[string]float64
+}
+
+// Comparison pairs the current and baseline windows of one subsystem on
+// relative time.
+type Comparison struct {
+	SubsystemID uint16                     `json:"subsystem_id"`
+	Points      []ComparisonPoint          `json:"points"`
+	Stats       map[string]DifferenceStats `json:"stats"`
+}
+
+// ComparisonPoint holds both windows' bucket averages at OffsetSeconds from
+// the window starts; a parameter is nil where its window has no data.
+type ComparisonPoint struct {
+	OffsetSeconds float64             `json:"offset_seconds"`
+	Current       map[string]*float64 `json:"current"`
+	Baseline      map[string]*float64 `json:"baseline"`
+}
+
+// DifferenceStats describes current minus baseline over the buckets where
+// both windows have data.
+type DifferenceStats struct {
+	Pairs                   int      `json:"pairs"`
+	CurrentMean             *float64 `json:"current_mean,omitempty"`
+	BaselineMean            *float64 `json:"baseline_mean,omitempty"`
+	MeanDiff                *float64 `json:"mean_diff,omitempty"`
+	MeanAbsDiff             *float64 `json:"mean_abs_diff,omitempty"`
+	RMSDiff                 *float64 `json:"rms_diff,omitempty"`
+	MaxAbsDiff              *float64 `json:"max_abs_diff,omitempty"`
+	MaxAbsDiffOffsetSeconds *float64 `json:"max_abs_diff_offset_seconds,omitempty"`
+}
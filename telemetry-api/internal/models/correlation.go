@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type CorrelationQuery struct {
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	X            string    `query:"x"`            // first parameter
+	Y            string    `query:"y"`            // second parameter
+	Interval     string    `query:"interval"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints    int       `query:"max_points"`   // target bucket count when interval is empty
+	MaxLag       int       `query:"max_lag"`      // buckets each way for cross-correlation; 0 skips it
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+}
+
+// Correlation relates two parameters of one subsystem after both are
+// averaged into common buckets. Coefficients are nil when undefined.
+type Correlation struct {
+	SubsystemID uint16           `json:"subsystem_id"`
+	X           string           `json:"x"`
+	Y           string           `json:"y"`
+	Pairs       int              `json:"pairs"`
+	Pearson     *float64         `json:"pearson"`
+	Spearman    *float64         `json:"spearman"`
+	Lagged      []LagCorrelation `json:"lagged,omitempty"`
+	BestLag     *LagCorrelation  `json:"best_lag,omitempty"` // strongest absolute correlation
+}
+
+// LagCorrelation is the Pearson correlation of x with y shifted by Lag
+// buckets; a positive lag means y follows x.
+type LagCorrelation struct {
+	Lag        int      `json:"lag"`
+	LagSeconds float64  `json:"lag_seconds"`
+	Pearson    *float64 `json:"pearson"`
+	Pairs      int      `json:"pairs"`
+}
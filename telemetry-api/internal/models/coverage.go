@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Gap causes. A gap is onboard when other telemetry was received while it
+// lasted, so the link was up and the subsystem produced nothing; it is a
+// ground-link gap when nothing at all was received. Gaps at the edges of the
+// range cannot be told apart and are unknown.
+const (
+	GapOnboard    = "onboard"
+	GapGroundLink = "ground_link"
+	GapUnknown    = "unknown"
+)
+
+type CoverageQuery struct {
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	Cadence      string    `query:"cadence"`      // default and per-subsystem, e.g. '1s,3:5s'
+	Tolerance    float64   `query:"tolerance"`    // multiple of the cadence that counts as a gap
+}
+
+// GapRow is a gap between consecutive samples, as read from the database.
+type GapRow struct {
+	SubsystemID uint16
+	Start       time.Time
+	End         time.Time
+	Cause       string
+}
+
+// SubsystemSpan is the first and last sample of a subsystem in the range.
+type SubsystemSpan struct {
+	SubsystemID uint16
+	First       time.Time
+	Last        time.Time
+	Samples     int
+}
+
+type Coverage struct {
+	SubsystemID     uint16     `json:"subsystem_id"`
+	CadenceSeconds  float64    `json:"cadence_seconds"`
+	Samples         int        `json:"samples"`
+	ExpectedSamples int        `json:"expected_samples"`
+	CoveragePct     float64    `json:"coverage_pct"`
+	Intervals       []Interval `json:"intervals"`
+	Gaps            []Gap      `json:"gaps"`
+}
+
+// Interval is a stretch of contiguous samples.
+type Interval struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+}
+
+type Gap struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Cause           string    `json:"cause"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last row of a page in (timestamp, id) order. Clients only
+// ever see it as an opaque token.
+type Cursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.Timestamp.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	before, after, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(before, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(after, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Timestamp: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Timestamp: time.Date(2024, time.March, 13, 12, 0, 0, 0, time.UTC), ID: 1},
+		{Timestamp: time.Date(2024, time.March, 13, 12, 0, 0, 123456789, time.UTC), ID: 9007199254740993},
+		{Timestamp: time.Date(1969, time.December, 31, 23, 59, 59, 500, time.UTC), ID: 42},
+		{Timestamp: time.Unix(0, 0).UTC(), ID: 0},
+	}
+
+	for _, want := range tests {
+		token := want.Encode()
+		got, err := DecodeCursor(token)
+		if err != nil {
+			t.Errorf("DecodeCursor(%q): %v", token, err)
+			continue
+		}
+		if !got.Timestamp.Equal(want.Timestamp) || got.ID != want.ID {
+			t.Errorf("DecodeCursor(%q) = %+v, want %+v", token, *got, want)
+		}
+		if got.Timestamp.Location() != time.UTC {
+			t.Errorf("DecodeCursor(%q) timestamp in %s, want UTC", token, got.Timestamp.Location())
+		}
+	}
+}
+
+func TestCursorEncodeInZone(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	at := time.Date(2024, time.March, 13, 14, 0, 0, 0, zone)
+
+	got, err := DecodeCursor(Cursor{Timestamp: at, ID: 7}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Timestamp.Equal(at) {
+		t.Errorf("got %s, want %s", got.Timestamp, at)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "not base64", token: "not a cursor!"},
+		{name: "no separator", token: encode("12345")},
+		{name: "missing id", token: encode("12345:")},
+		{name: "non-numeric", token: encode("abc:def")},
+		{name: "trailing garbage", token: encode("1:2xyz")},
+		{name: "extra field", token: encode("1:2:3")},
+		{name: "leading space", token: encode(" 1:2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := DecodeCursor(tt.token)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %+v, %v; want ErrInvalidCursor", cursor, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	ExportTelemetry = "telemetry"
+	ExportAnomalies = "anomalies"
+)
+
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportCancelled = "cancelled"
+	ExportExpired   = "expired"
+)
+
+// ExportRequest describes an asynchronous export. Filters holds value
+// predicates keyed as in the query string, e.g. {"battery_lt": "50"}, with
+// values in the requested units.
+type ExportRequest struct {
+	Kind         string            `json:"kind"`        // 'telemetry' or 'anomalies'
+	Format       string            `json:"format"`      // 'csv', 'ndjson' or 'parquet'
+	Compression  string            `json:"compression"` // 'gzip' or 'none'
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	SubsystemIDs []uint16          `json:"subsystem_id,omitempty"`
+	HasAnomaly   *bool             `json:"has_anomaly,omitempty"`
+	Filters      map[string]string `json:"filters,omitempty"`
+	Units        string            `json:"units,omitempty"` // as the units query parameter; empty for stored units
+}
+
+// Query converts the request into the query used by the export streams.
+func (r ExportRequest) Query() (*TelemetryQuery, error) {
+	query := &TelemetryQuery{
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+		SubsystemIDs: r.SubsystemIDs,
+		HasAnomaly:   r.HasAnomaly,
+		Format:       r.Format,
+		Compression:  r.Compression,
+		Units:        r.Units,
+	}
+
+	keys := make([]string, 0, len(r.Filters))
+	for key := range r.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		predicate, ok, err := ParseValuePredicate(key, r.Filters[key])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", key)
+		}
+		query.Predicates = append(query.Predicates, predicate)
+	}
+	return query, nil
+}
+
+type ExportJob struct {
+	ID            string        `json:"id"`
+	Status        string        `json:"status"`
+	Request       ExportRequest `json:"request"`
+	RowsEstimated int64         `json:"rows_estimated"`
+	RowsWritten   int64         `json:"rows_written"`
+	BytesWritten  int64         `json:"bytes_written"`
+	Progress      float64       `json:"progress"` // 0 to 1; estimated while running
+	Error         string        `json:"error,omitempty"`
+	DownloadURL   string        `json:"download_url,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	StartedAt     *time.Time    `json:"started_at,omitempty"`
+	FinishedAt    *time.Time    `json:"finished_at,omitempty"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"`
+	FilePath      string        `json:"-"`
+}
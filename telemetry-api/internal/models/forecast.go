@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Forecast statuses.
+const (
+	ForecastViolating = "violating" // the fitted value is already outside the normal band
+	ForecastCrossing  = "crossing"  // the trend reaches a limit in the future
+	ForecastStable    = "stable"    // the trend does not head for a limit
+)
+
+type ForecastQuery struct {
+	Parameter    string    `query:"parameter"`
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	At           time.Time `query:"-"`            // at, the forecast origin, defaults to now; read by parseTimes
+	Lookback     string    `query:"lookback"`     // fit window before at, e.g. '24h'
+	Interval     string    `query:"interval"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints    int       `query:"max_points"`   // target bucket count when interval is empty
+	Confidence   float64   `query:"confidence"`   // 0.8, 0.9, 0.95 or 0.99
+	Horizon      string    `query:"horizon"`      // flags crossings expected within this long, e.g. '72h'
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// Forecast extrapolates a robust trend of one parameter of one subsystem to
+// the limit of the normal band it is heading for. Slopes are per hour. The
+// ETA bounds come from the slope confidence interval; ETALatest is nil when
+// the slower bound never reaches the limit.
+type Forecast struct {
+	SubsystemID   uint16     `json:"subsystem_id"`
+	Parameter     string     `json:"parameter"`
+	Samples       int        `json:"samples"`
+	Status        string     `json:"status"`
+	Fitted        *float64   `json:"fitted,omitempty"` // trend value at the forecast origin
+	Slope         *float64   `json:"slope_per_hour,omitempty"`
+	SlopeLow      *float64   `json:"slope_low_per_hour,omitempty"`
+	SlopeHigh     *float64   `json:"slope_high_per_hour,omitempty"`
+	Limit         *float64   `json:"limit,omitempty"`
+	ETA           *time.Time `json:"eta,omitempty"`
+	ETAEarliest   *time.Time `json:"eta_earliest,omitempty"`
+	ETALatest     *time.Time `json:"eta_latest,omitempty"`
+	HoursToLimit  *float64   `json:"hours_to_limit,omitempty"`
+	WithinHorizon bool       `json:"within_horizon"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type HistogramQuery struct {
+	StartTime    time.Time `query:"-"` // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"` // end_time, read by parseTimes
+	Parameter    string    `query:"parameter"`
+	Bins         int       `query:"bins"`         // bin count, ignored when bin_width is set
+	BinWidth     float64   `query:"bin_width"`    // bin width in the requested units
+	Min          *float64  `query:"min"`          // lower edge, defaults to the smallest value
+	Max          *float64  `query:"max"`          // upper edge, defaults to the largest value
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// Histogram counts the values of one parameter of one subsystem. Values below
+// the first or above the last bin are counted in Underflow and Overflow.
+type Histogram struct {
+	SubsystemID uint16         `json:"subsystem_id"`
+	Parameter   string         `json:"parameter"`
+	Count       int            `json:"count"`
+	Underflow   int            `json:"underflow"`
+	Overflow    int            `json:"overflow"`
+	Bins        []HistogramBin `json:"bins"`
+	Limits      []LimitMarker  `json:"limits"`
+}
+
+// HistogramBin covers [Lower, Upper). Band is the severity of the bin's
+// values, or "mixed" when a limit falls inside the bin; Limits names those.
+type HistogramBin struct {
+	Lower  float64  `json:"lower"`
+	Upper  float64  `json:"upper"`
+	Count  int      `json:"count"`
+	Band   string   `json:"band"`
+	Limits []string `json:"limits,omitempty"`
+}
+
+// LimitMarker is one finite bound of a parameter's limit bands.
+type LimitMarker struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+const BandMixed = "mixed"
+
+// Markers lists the finite bounds of l, lowest first.
+func (l Limits) Markers() []LimitMarker {
+	var markers []LimitMarker
+	for _, m := range []LimitMarker{
+		{Name: "critical_min", Value: l.CriticalMin},
+		{Name: "normal_min", Value: l.NormalMin},
+		{Name: "normal_max", Value: l.NormalMax},
+		{Name: "critical_max", Value: l.CriticalMax},
+	} {
+		if !math.IsInf(m.Value, 0) {
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+// Annotate sets the band of a bin and the limits that fall inside it.
+func (l Limits) Annotate(bin *HistogramBin) {
+	for _, m := range l.Markers() {
+		if m.Value > bin.Lower && m.Value < bin.Upper {
+			bin.Limits = append(bin.Limits, m.Name)
+		}
+	}
+	if len(bin.Limits) > 0 {
+		bin.Band = BandMixed
+		return
+	}
+	bin.Band = l.Severity((bin.Lower + bin.Upper) / 2)
+}
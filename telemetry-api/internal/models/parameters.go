@@ -0,0 +1,117 @@
+package models
+
+import "math"
+
+const (
+	SeverityNormal   = "normal"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Parameters lists the telemetry columns in their canonical order.
+var Parameters = []string{"temperature", "battery", "altitude", "signal"}
+
+// Limits mirrors the validation ranges applied by telemetry-ingest. Values
+// outside the normal band are warnings; values beyond the critical bounds
+// are critical.
+type Limits struct {
+	NormalMin   float64
+	NormalMax   float64
+	CriticalMin float64
+	CriticalMax float64
+}
+
+var ParameterLimits = map[string]Limits{
+	"temperature": {NormalMin: 20, NormalMax: 30, CriticalMin: math.Inf(-1), CriticalMax: 35},
+	"battery":     {NormalMin: 70, NormalMax: 100, CriticalMin: 40, CriticalMax: math.Inf(1)},
+	"altitude":    {NormalMin: 500, NormalMax: 550, CriticalMin: 400, CriticalMax: math.Inf(1)},
+	"signal":      {NormalMin: -60, NormalMax: -40, CriticalMin: -80, CriticalMax: math.Inf(1)},
+}
+
+// anomalyParameters maps each anomaly_type to the parameter it was raised on.
+var anomalyParameters = map[string]string{
+	"high_temperature": "temperature",
+	"low_temperature":  "temperature",
+	"low_battery":      "battery",
+	"low_altitude":     "altitude",
+	"weak_signal":      "signal",
+
+	"predicted_temperature_violation": "temperature",
+	"predicted_battery_violation":     "battery",
+	"predicted_altitude_violation":    "altitude",
+	"predicted_signal_violation":      "signal",
+}
+
+func IsParameter(name string) bool {
+	_, ok := ParameterLimits[name]
+	return ok
+}
+
+func (l Limits) Severity(value float64) string {
+	if value < l.CriticalMin || value > l.CriticalMax {
+		return SeverityCritical
+	}
+	if value < l.NormalMin || value > l.NormalMax {
+		return SeverityWarning
+	}
+	return SeverityNormal
+}
+
+// SeverityRank orders severities so filters can ask for a minimum level.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r TelemetryRecord) Value(parameter string) (float32, bool) {
+	switch parameter {
+	case "temperature":
+		return r.Temperature, true
+	case "battery":
+		return r.Battery, true
+	case "altitude":
+		return r.Altitude, true
+	case "signal":
+		return r.Signal, true
+	}
+	return 0, false
+}
+
+// Project returns the record with only the requested parameters.
+func (r TelemetryRecord) Project(parameters []string) map[string]interface{} {
+	projected := map[string]interface{}{
+		"id":           r.ID,
+		"timestamp":    r.Timestamp,
+		"subsystem_id": r.SubsystemID,
+		"has_anomaly":  r.HasAnomaly,
+	}
+	for _, p := range parameters {
+		if v, ok := r.Value(p); ok {
+			projected[p] = v
+		}
+	}
+	return projected
+}
+
+// AnomalyParameter returns the parameter an anomaly type is raised on.
+func AnomalyParameter(anomalyType string) string {
+	return anomalyParameters[anomalyType]
+}
+
+func (a AnomalyRecord) Parameter() string {
+	return AnomalyParameter(a.AnomalyType)
+}
+
+func (a AnomalyRecord) Severity() string {
+	limits, ok := ParameterLimits[a.Parameter()]
+	if !ok || limits.Severity(float64(a.Value)) != SeverityCritical {
+		return SeverityWarning
+	}
+	return SeverityCritical
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValuePredicateOps maps each query suffix to its SQL operator. 'between'
+// takes two comma-separated bounds and is inclusive.
+var ValuePredicateOps = map[string]string{
+	"lt":      "<",
+	"lte":     "<=",
+	"gt":      ">",
+	"gte":     ">=",
+	"eq":      "=",
+	"between": "BETWEEN",
+}
+
+// ValuePredicate is a filter such as battery_lt=50 or temperature_between=30,40.
+type ValuePredicate struct {
+	Parameter string
+	Op        string
+	Values    []float64
+}
+
+// ParseValuePredicate parses a query key and value into a predicate. ok is
+// false when the key is not a predicate at all.
+func ParseValuePredicate(key, value string) (predicate ValuePredicate, ok bool, err error) {
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return ValuePredicate{}, false, nil
+	}
+	parameter, op := key[:i], key[i+1:]
+	if !IsParameter(parameter) {
+		return ValuePredicate{}, false, nil
+	}
+	if _, known := ValuePredicateOps[op]; !known {
+		return ValuePredicate{}, true, fmt.Errorf("unknown operator %q in %s", op, key)
+	}
+
+	bounds := strings.Split(value, ",")
+	if op == "between" && len(bounds) != 2 {
+		return ValuePredicate{}, true, fmt.Errorf("%s expects two comma-separated values", key)
+	}
+	if op != "between" && len(bounds) != 1 {
+		return ValuePredicate{}, true, fmt.Errorf("%s expects a single value", key)
+	}
+
+	predicate = ValuePredicate{Parameter: parameter, Op: op}
+	for _, bound := range bounds {
+		v, err := strconv.ParseFloat(strings.TrimSpace(bound), 64)
+		if err != nil {
+			return ValuePredicate{}, true, fmt.Errorf("%s must be numeric", key)
+		}
+		predicate.Values = append(predicate.Values, v)
+	}
+	return predicate, true, nil
+}
+
+// Matches reports whether r satisfies the predicate. Bounds are compared at
+// the float32 precision values are stored in, so eq matches a value as it was
+// stored.
+func (p ValuePredicate) Matches(r TelemetryRecord) bool {
+	value, ok := r.Value(p.Parameter)
+	if !ok {
+		return false
+	}
+	bound := func(i int) float32 { return float32(p.Values[i]) }
+
+	switch p.Op {
+	case "lt":
+		return value < bound(0)
+	case "lte":
+		return value <= bound(0)
+	case "gt":
+		return value > bound(0)
+	case "gte":
+		return value >= bound(0)
+	case "eq":
+		return value == bound(0)
+	case "between":
+		return value >= bound(0) && value <= bound(1)
+	}
+	return false
+}
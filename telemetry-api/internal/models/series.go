@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Fill strategies for gap-filled series.
+const (
+	FillNull        = "null"
+	FillLOCF        = "locf"
+	FillInterpolate = "interpolate"
+)
+
+// Point quality flags. A point is measured when its bucket had samples;
+// otherwise it was filled according to the strategy, or left missing.
+const (
+	QualityMeasured     = "measured"
+	QualityCarried      = "carried"
+	QualityInterpolated = "interpolated"
+	QualityMissing      = "missing"
+)
+
+type SeriesQuery struct {
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	Interval     string    `query:"interval"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints    int       `query:"max_points"`   // target bucket count when interval is empty
+	Fill         string    `query:"fill"`         // 'null', 'locf' or 'interpolate'
+	Parameters   string    `query:"parameters"`   // comma-separated, defaults to all
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	TimeSystem   string    `query:"time_system"`  // 'UTC', 'TAI', 'GPS' or 'MET'
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// Series is a regular, gap-filled series for one subsystem: every bucket in
+// the range has a point.
+type Series struct {
+	SubsystemID uint16        `json:"subsystem_id"`
+	Points      []SeriesPoint `json:"points"`
+}
+
+// SeriesPoint holds the bucket average of each parameter. Values is nil for a
+// parameter that is missing after filling.
+type SeriesPoint struct {
+	Timestamp time.Time           `json:"timestamp"`
+	Count     int                 `json:"count"`
+	Values    map[string]*float64 `json:"values"`
+	Quality   map[string]string   `json:"quality"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type SnapshotQuery struct {
+	Time         time.Time `query:"-"`            // time, read by parseTimes
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	MaxAge       string    `query:"max_age"`      // e.g. '1h'; older values are left out
+	TimeSystem   string    `query:"time_system"`  // 'UTC', 'TAI', 'GPS' or 'MET'
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// Snapshot is the state of one subsystem as of Time: the last known value of
+// each parameter at or before that instant.
+type Snapshot struct {
+	SubsystemID uint16                   `json:"subsystem_id"`
+	Time        time.Time                `json:"time"`
+	RecordID    int64                    `json:"record_id"`
+	HasAnomaly  bool                     `json:"has_anomaly"`
+	Values      map[string]SnapshotValue `json:"values"`
+}
+
+type SnapshotValue struct {
+	Value      float32   `json:"value"`
+	Timestamp  time.Time `json:"timestamp"`
+	AgeSeconds float64   `json:"age_seconds"`
+}
+
+// NewSnapshot describes record as seen from at.
+func NewSnapshot(record TelemetryRecord, at time.Time) Snapshot {
+	snapshot := Snapshot{
+		SubsystemID: record.SubsystemID,
+		Time:        at,
+		RecordID:    record.ID,
+		HasAnomaly:  record.HasAnomaly,
+		Values:      make(map[string]SnapshotValue, len(Parameters)),
+	}
+	for _, p := range Parameters {
+		v, _ := record.Value(p)
+		snapshot.Values[p] = SnapshotValue{
+			Value:      v,
+			Timestamp:  record.Timestamp,
+			AgeSeconds: at.Sub(record.Timestamp).Seconds(),
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type SpectrumQuery struct {
+	StartTime    time.Time `query:"-"` // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"` // end_time, read by parseTimes
+	Parameter    string    `query:"parameter"`
+	Interval     string    `query:"interval"`     // resampling interval, one of AggregationIntervals
+	MaxPoints    int       `query:"max_points"`   // target sample count when interval is empty
+	Peaks        int       `query:"peaks"`        // number of dominant frequencies to return
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// Spectrum is the power spectral density of one parameter of one subsystem,
+// with its dominant frequencies strongest first.
+type Spectrum struct {
+	SubsystemID           uint16        `json:"subsystem_id"`
+	Parameter             string        `json:"parameter"`
+	Samples               int           `json:"samples"`
+	SampleIntervalSeconds float64       `json:"sample_interval_seconds"`
+	Peaks                 []SpectrumBin `json:"peaks"`
+	PSD                   []SpectrumBin `json:"psd"`
+}
+
+// SpectrumBin is one frequency bin. Power is a density in the parameter's
+// units squared per Hz.
+type SpectrumBin struct {
+	FrequencyHz   float64  `json:"frequency_hz"`
+	PeriodSeconds *float64 `json:"period_seconds,omitempty"` // nil for the DC bin
+	Power         float64  `json:"power"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// StatFunctions lists the statistics returned by default, in order.
+// out_of_limits_pct is the share of time spent outside the normal band of
+// ParameterLimits; further percentiles (p1 to p99) may also be requested.
+// Percentiles and out_of_limits_pct need the raw samples, so only requests
+// limited to min, max, mean, stddev and count can read the continuous
+// aggregates; the defaults always read raw rows.
+var StatFunctions = []string{"min", "max", "mean", "stddev", "p5", "p50", "p95", "count", "out_of_limits_pct"}
+
+// IsStat reports whether name is a supported statistic.
+func IsStat(name string) bool {
+	for _, stat := range StatFunctions {
+		if stat == name {
+			return true
+		}
+	}
+	_, ok := Percentile(name)
+	return ok
+}
+
+type StatsQuery struct {
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	Parameters   string    `query:"parameters"`   // comma-separated, defaults to all
+	Stats        string    `query:"stats"`        // comma-separated, defaults to StatFunctions
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	TimeSystem   string    `query:"time_system"`  // 'UTC', 'TAI', 'GPS' or 'MET'
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+}
+
+// ParameterStats holds the statistics of each parameter of one subsystem over
+// the whole range, keyed by parameter and then by statistic. Statistics that
+// are undefined (e.g. stddev of a single sample) are omitted.
+type ParameterStats struct {
+	SubsystemID uint16                        `json:"subsystem_id"`
+	Count       int                           `json:"count"`
+	Values      map[string]map[string]float64 `json:"values"`
+}
@@ -3,6 +3,8 @@ package models
 import "time"
 
 type TelemetryRecord struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"-"` // when the row was stored; orders stream resumption
 	Timestamp   time.Time `json:"timestamp"`
 	SubsystemID uint16    `json:"subsystem_id"`
 	Temperature float32   `json:"temperature"`
@@ -13,6 +15,8 @@ type TelemetryRecord struct {
 }
 
 type AnomalyRecord struct {
+	ID            int64     `json:"id"`
+	CreatedAt     time.Time `json:"-"` // when the row was stored; orders stream resumption
 	Timestamp     time.Time `json:"timestamp"`
 	SubsystemID   uint16    `json:"subsystem_id"`
 	AnomalyType   string    `json:"anomaly_type"`
@@ -20,31 +24,76 @@ type AnomalyRecord struct {
 	ExpectedRange string    `json:"expected_range"`
 }
 
+type Subsystem struct {
+	ID       uint16    `json:"subsystem_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
 type TelemetryQuery struct {
-	StartTime   time.Time `query:"start_time"`
-	EndTime     time.Time `query:"end_time"`
-	SubsystemID *uint16   `query:"subsystem_id"`
-	Page        int       `query:"page" default:"1"`
-	PageSize    int       `query:"page_size" default:"100"`
-	Format      string    `query:"format" default:"raw"` // 'raw' or 'chart'
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	Page         int       `query:"page" default:"1"`
+	PageSize     int       `query:"page_size" default:"100"`
+	Cursor       string    `query:"cursor"`               // next_cursor from a previous page; replaces page
+	Total        string    `query:"total"`                // 'exact', 'estimate' or 'none'
+	Format       string    `query:"format" default:"raw"` // 'raw' or 'chart'; 'csv', 'ndjson' or 'parquet' for exports
+	MaxPoints    int       `query:"max_points"`           // per-series point budget for 'chart'
+	Fields       string    `query:"fields"`               // comma-separated parameters to return
+	HasAnomaly   *bool     `query:"has_anomaly"`
+	Compression  string    `query:"compression"` // 'gzip' or 'none' for exports
+	TimeSystem   string    `query:"time_system"` // 'UTC', 'TAI', 'GPS' or 'MET'
+	Units        string    `query:"units"`       // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
+
+	After      *Cursor          `query:"-"` // decoded Cursor
+	Projection []string         `query:"-"` // parsed Fields
+	Predicates []ValuePredicate `query:"-"` // parsed <parameter>_<op> filters
 }
 
 type TelemetryAggregationQuery struct {
-	StartTime   time.Time `query:"start_time"`
-	EndTime     time.Time `query:"end_time"`
-	GroupBy     string    `query:"group_by"`    // '1m', '1h', '1d'
-	Aggregation string    `query:"aggregation"` // 'min', 'max', 'avg', 'count'
-	SubsystemID *uint16   `query:"subsystem_id"`
+	StartTime    time.Time `query:"-"`            // start_time, read by parseTimes
+	EndTime      time.Time `query:"-"`            // end_time, read by parseTimes
+	GroupBy      string    `query:"group_by"`     // one of AggregationIntervals, chosen from max_points if empty
+	MaxPoints    int       `query:"max_points"`   // target bucket count when group_by is empty
+	Aggregation  string    `query:"aggregation"`  // comma-separated, e.g. 'min,max,avg,p95'
+	Parameters   string    `query:"parameters"`   // comma-separated, defaults to all
+	SubsystemIDs []uint16  `query:"subsystem_id"` // repeated or comma-separated; empty means all
+	TimeSystem   string    `query:"time_system"`  // 'UTC', 'TAI', 'GPS' or 'MET'
+	Units        string    `query:"units"`        // unit system and/or parameter:unit pairs, e.g. 'si,altitude:km'
 }
 
 type TelemetryResponse struct {
-	Data     interface{} `json:"data"`
-	Metadata struct {
-		TotalCount int       `json:"total_count"`
-		PageCount  int       `json:"page_count"`
-		HasMore    bool      `json:"has_more"`
-		TimeRange  TimeRange `json:"time_range"`
-	} `json:"metadata"`
+	Data     interface{}      `json:"data"`
+	Metadata ResponseMetadata `json:"metadata"`
+}
+
+type ResponseMetadata struct {
+	TotalCount     int               `json:"total_count"`
+	TotalEstimated bool              `json:"total_estimated,omitempty"`
+	PageCount      int               `json:"page_count"`
+	HasMore        bool              `json:"has_more"`
+	NextCursor     string            `json:"next_cursor,omitempty"`
+	TimeRange      TimeRange         `json:"time_range"`
+	BaselineRange  *TimeRange        `json:"baseline_range,omitempty"`
+	GroupBy        string            `json:"group_by,omitempty"`
+	Resolution     string            `json:"resolution,omitempty"` // 'raw' or the continuous aggregate used
+	Fill           string            `json:"fill,omitempty"`
+	TimeSystem     string            `json:"time_system,omitempty"` // system of the data timestamps; time_range is UTC
+	Units          map[string]string `json:"units,omitempty"`       // unit of each parameter's values
+}
+
+const (
+	TotalExact    = "exact"
+	TotalEstimate = "estimate"
+	TotalNone     = "none"
+)
+
+// PageInfo describes where a page of results sits in the full result set.
+type PageInfo struct {
+	TotalCount     int
+	TotalEstimated bool
+	HasMore        bool
+	NextCursor     string
 }
 
 type TimeRange struct {
@@ -52,11 +101,25 @@ type TimeRange struct {
 	End   time.Time `json:"end"`
 }
 
+// AggregatedMetric holds one time bucket. Values is keyed by parameter and
+// then by aggregation function; functions that are undefined for a bucket
+// (e.g. stddev of a single sample) are omitted.
 type AggregatedMetric struct {
-	Timestamp   time.Time `json:"timestamp"`
-	SubsystemID uint16    `json:"subsystem_id"`
-	Min         float32   `json:"min"`
-	Max         float32   `json:"max"`
-	Avg         float32   `json:"avg"`
-	Count       int       `json:"count"`
+	Timestamp   time.Time                     `json:"timestamp"`
+	SubsystemID uint16                        `json:"subsystem_id"`
+	Count       int                           `json:"count"`
+	Values      map[string]map[string]float64 `json:"values"`
+}
+
+// ChartSeries is a downsampled series for one parameter of one subsystem.
+type ChartSeries struct {
+	SubsystemID uint16       `json:"subsystem_id"`
+	Parameter   string       `json:"parameter"`
+	Points      []ChartPoint `json:"points"`
+}
+
+type ChartPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float32   `json:"value"`
+	Anomaly   bool      `json:"anomaly,omitempty"`
 }
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Unit converts a parameter from its canonical unit: value*Scale + Offset.
+type Unit struct {
+	Symbol string
+	Scale  float64
+	Offset float64
+}
+
+// parameterUnits lists the units each parameter can be reported in; the first
+// is the unit it is stored in. Signal strength is a dB level relative to a
+// reference that depends on the receiver, so dBm is only available once
+// SetSignalReference has been called.
+var parameterUnits = map[string][]Unit{
+	"temperature": {
+		{Symbol: "degC", Scale: 1},
+		{Symbol: "K", Scale: 1, Offset: 273.15},
+		{Symbol: "degF", Scale: 1.8, Offset: 32},
+	},
+	"battery": {
+		{Symbol: "percent", Scale: 1},
+		{Symbol: "fraction", Scale: 0.01},
+	},
+	"altitude": {
+		{Symbol: "km", Scale: 1},
+		{Symbol: "m", Scale: 1000},
+		{Symbol: "ft", Scale: 1000 / 0.3048},
+		{Symbol: "mi", Scale: 1 / 1.609344},
+	},
+	"signal": {
+		{Symbol: "dB", Scale: 1},
+	},
+}
+
+// SetSignalReference makes signal available in dBm, given the power in dBm
+// that a 0 dB reading corresponds to. It must be called at startup, before
+// any units are parsed.
+func SetSignalReference(dBm float64) {
+	parameterUnits["signal"] = append(parameterUnits["signal"][:1:1], Unit{Symbol: "dBm", Scale: 1, Offset: dBm})
+}
+
+// UnitSystems are named sets of units; parameters they leave out stay in
+// their canonical unit.
+var UnitSystems = map[string]map[string]string{
+	"canonical": {},
+	"si":        {"temperature": "K", "battery": "fraction", "altitude": "m"},
+	"imperial":  {"temperature": "degF", "altitude": "ft"},
+}
+
+// Units maps every parameter to the unit its values are reported in.
+type Units map[string]Unit
+
+// ParseUnits reads a comma-separated units option. Each entry is a unit
+// system name or a parameter:unit pair; later entries override earlier ones,
+// so "si,temperature:degC" reports temperature in degC and the rest in SI
+// units.
+func ParseUnits(spec string) (Units, error) {
+	units := make(Units, len(Parameters))
+	for _, p := range Parameters {
+		units[p] = parameterUnits[p][0]
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parameter, symbol, ok := strings.Cut(entry, ":")
+		if !ok {
+			system, ok := UnitSystems[entry]
+			if !ok {
+				return nil, fmt.Errorf("unknown unit system %q; use %s or parameter:unit", entry, strings.Join(unitSystemNames(), ", "))
+			}
+			for parameter, symbol := range system {
+				units[parameter], _ = findUnit(parameter, symbol)
+			}
+			continue
+		}
+
+		if !IsParameter(parameter) {
+			return nil, fmt.Errorf("unknown parameter %q in units", parameter)
+		}
+		unit, ok := findUnit(parameter, symbol)
+		if !ok && parameter == "signal" && symbol == "dBm" {
+			return nil, fmt.Errorf("signal:dBm is unavailable; no signal reference level is configured")
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q for %s", symbol, parameter)
+		}
+		units[parameter] = unit
+	}
+
+	return units, nil
+}
+
+func findUnit(parameter, symbol string) (Unit, bool) {
+	for _, unit := range parameterUnits[parameter] {
+		if unit.Symbol == symbol {
+			return unit, true
+		}
+	}
+	return Unit{}, false
+}
+
+func unitSystemNames() []string {
+	names := make([]string, 0, len(UnitSystems))
+	for name := range UnitSystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value converts a canonical value of parameter. Parameters without a unit,
+// such as those of unmapped anomaly types, are returned unchanged.
+func (u Units) Value(parameter string, v float64) float64 {
+	unit, ok := u[parameter]
+	if !ok {
+		return v
+	}
+	return v*unit.Scale + unit.Offset
+}
+
+// Delta converts a difference, spread or rate, which the offset cancels out of.
+func (u Units) Delta(parameter string, v float64) float64 {
+	unit, ok := u[parameter]
+	if !ok {
+		return v
+	}
+	return v * unit.Scale
+}
+
+// Canonical converts a value given in the reported unit back to the stored one.
+func (u Units) Canonical(parameter string, v float64) float64 {
+	unit, ok := u[parameter]
+	if !ok {
+		return v
+	}
+	return (v - unit.Offset) / unit.Scale
+}
+
+// Statistic converts an aggregation or statistic of parameter by kind:
+// counts and percentages are unitless and standard deviations are spreads.
+func (u Units) Statistic(parameter, name string, v float64) float64 {
+	switch name {
+	case "count", "out_of_limits_pct":
+		return v
+	case "stddev":
+		return u.Delta(parameter, v)
+	}
+	return u.Value(parameter, v)
+}
+
+// Limits returns the limit bands of parameter in the reported unit.
+func (u Units) Limits(parameter string) Limits {
+	limits := ParameterLimits[parameter]
+	return Limits{
+		NormalMin:   u.Value(parameter, limits.NormalMin),
+		NormalMax:   u.Value(parameter, limits.NormalMax),
+		CriticalMin: u.Value(parameter, limits.CriticalMin),
+		CriticalMax: u.Value(parameter, limits.CriticalMax),
+	}
+}
+
+// Symbols reports the unit of each parameter, for response metadata.
+func (u Units) Symbols() map[string]string {
+	symbols := make(map[string]string, len(u))
+	for parameter, unit := range u {
+		symbols[parameter] = unit.Symbol
+	}
+	return symbols
+}
+
+// ConvertRecord converts the parameters of r in place.
+func (u Units) ConvertRecord(r *TelemetryRecord) {
+	r.Temperature = float32(u.Value("temperature", float64(r.Temperature)))
+	r.Battery = float32(u.Value("battery", float64(r.Battery)))
+	r.Altitude = float32(u.Value("altitude", float64(r.Altitude)))
+	r.Signal = float32(u.Value("signal", float64(r.Signal)))
+}
+
+// predictedRange matches the forecast note that follows the normal band in a
+// predicted violation's expected range, e.g. "(battery expected below 70 by
+// 2024-03-13T12:00:00Z)".
+var predictedRange = regexp.MustCompile(`\((\w+) expected (below|above) (\S+) by (\S+)\)$`)
+
+// ConvertAnomaly converts the value of a in place. The expected range is
+// rewritten from the parameter's normal band in the reported unit, so that it
+// agrees with the value.
+func (u Units) ConvertAnomaly(a *AnomalyRecord) {
+	parameter := a.Parameter()
+	unit, ok := u[parameter]
+	if !ok {
+		return
+	}
+	a.Value = float32(u.Value(parameter, float64(a.Value)))
+	if unit == parameterUnits[parameter][0] {
+		return
+	}
+
+	limits := u.Limits(parameter)
+	expected := fmt.Sprintf("%s - %s %s", formatLimit(limits.NormalMin), formatLimit(limits.NormalMax), unit.Symbol)
+	if m := predictedRange.FindStringSubmatch(a.ExpectedRange); m != nil {
+		if limit, err := strconv.ParseFloat(m[3], 64); err == nil {
+			expected += fmt.Sprintf(" (%s expected %s %s %s by %s)", m[1], m[2], formatLimit(u.Value(parameter, limit)), unit.Symbol, m[4])
+		}
+	}
+	a.ExpectedRange = expected
+}
+
+// formatLimit renders a converted limit to two decimal places, dropping
+// trailing zeros.
+func formatLimit(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// CanonicalPredicates converts predicate values given in the reported units
+// back to the stored ones, in place.
+func (u Units) CanonicalPredicates(predicates []ValuePredicate) {
+	for _, p := range predicates {
+		for i, v := range p.Values {
+			p.Values[i] = u.Canonical(p.Parameter, v)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{spec: "", want: map[string]string{"temperature": "degC", "battery": "percent", "altitude": "km", "signal": "dB"}},
+		{spec: "canonical", want: map[string]string{"temperature": "degC", "battery": "percent", "altitude": "km", "signal": "dB"}},
+		{spec: "si", want: map[string]string{"temperature": "K", "battery": "fraction", "altitude": "m", "signal": "dB"}},
+		{spec: "imperial", want: map[string]string{"temperature": "degF", "battery": "percent", "altitude": "ft", "signal": "dB"}},
+		{spec: "si,temperature:degC", want: map[string]string{"temperature": "degC", "battery": "fraction", "altitude": "m", "signal": "dB"}},
+		{spec: "altitude:mi, si", want: map[string]string{"temperature": "K", "battery": "fraction", "altitude": "m", "signal": "dB"}},
+		{spec: " altitude:mi ,", want: map[string]string{"temperature": "degC", "battery": "percent", "altitude": "mi", "signal": "dB"}},
+		{spec: "metric", wantErr: true},
+		{spec: "pressure:Pa", wantErr: true},
+		{spec: "temperature:km", wantErr: true},
+		{spec: "signal:dBm", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			units, err := ParseUnits(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", units.Symbols())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			symbols := units.Symbols()
+			if len(symbols) != len(tt.want) {
+				t.Fatalf("got %v, want %v", symbols, tt.want)
+			}
+			for parameter, symbol := range tt.want {
+				if symbols[parameter] != symbol {
+					t.Errorf("%s: got %s, want %s", parameter, symbols[parameter], symbol)
+				}
+			}
+		})
+	}
+}
+
+func TestUnitsConversion(t *testing.T) {
+	units, err := ParseUnits("imperial,battery:fraction")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		parameter string
+		canonical float64
+		value     float64
+		delta     float64
+	}{
+		{parameter: "temperature", canonical: 100, value: 212, delta: 180},
+		{parameter: "temperature", canonical: -40, value: -40, delta: -72},
+		{parameter: "battery", canonical: 50, value: 0.5, delta: 0.5},
+		{parameter: "altitude", canonical: 0.3048, value: 1000, delta: 1000},
+		{parameter: "signal", canonical: -70, value: -70, delta: -70},
+	}
+
+	for _, tt := range tests {
+		if got := units.Value(tt.parameter, tt.canonical); math.Abs(got-tt.value) > 1e-9 {
+			t.Errorf("Value(%s, %g) = %g, want %g", tt.parameter, tt.canonical, got, tt.value)
+		}
+		if got := units.Delta(tt.parameter, tt.canonical); math.Abs(got-tt.delta) > 1e-9 {
+			t.Errorf("Delta(%s, %g) = %g, want %g", tt.parameter, tt.canonical, got, tt.delta)
+		}
+		if got := units.Canonical(tt.parameter, tt.value); math.Abs(got-tt.canonical) > 1e-9 {
+			t.Errorf("Canonical(%s, %g) = %g, want %g", tt.parameter, tt.value, got, tt.canonical)
+		}
+	}
+}
+
+func TestSignalReference(t *testing.T) {
+	if _, err := ParseUnits("signal:dBm"); err == nil {
+		t.Fatal("expected an error for dBm without a reference level")
+	}
+
+	SetSignalReference(-30)
+	t.Cleanup(func() { parameterUnits["signal"] = parameterUnits["signal"][:1] })
+
+	units, err := ParseUnits("si,signal:dBm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := units.Symbols()["signal"]; got != "dBm" {
+		t.Errorf("got signal in %s, want dBm", got)
+	}
+	if got := units.Value("signal", -50); got != -80 {
+		t.Errorf("Value(signal, -50) = %g, want -80", got)
+	}
+	if got := units.Delta("signal", 3); got != 3 {
+		t.Errorf("Delta(signal, 3) = %g, want 3", got)
+	}
+
+	// Setting the reference again replaces it rather than adding a unit.
+	SetSignalReference(0)
+	if n := len(parameterUnits["signal"]); n != 2 {
+		t.Errorf("got %d signal units, want 2", n)
+	}
+}
+
+func TestConvertAnomaly(t *testing.T) {
+	tests := []struct {
+		name     string
+		units    string
+		anomaly  AnomalyRecord
+		value    float32
+		expected string
+	}{
+		{
+			name:     "canonical units keep the stored range",
+			anomaly:  AnomalyRecord{AnomalyType: "high_temperature", Value: 36, ExpectedRange: "20.0°C - 30.0°C"},
+			value:    36,
+			expected: "20.0°C - 30.0°C",
+		},
+		{
+			name:     "converted",
+			units:    "si",
+			anomaly:  AnomalyRecord{AnomalyType: "high_temperature", Value: 36, ExpectedRange: "20.0°C - 30.0°C"},
+			value:    309.15,
+			expected: "293.15 - 303.15 K",
+		},
+		{
+			name:     "other parameters unaffected",
+			units:    "altitude:m",
+			anomaly:  AnomalyRecord{AnomalyType: "low_battery", Value: 60, ExpectedRange: "70% - 100%"},
+			value:    60,
+			expected: "70% - 100%",
+		},
+		{
+			name:     "predicted violation",
+			units:    "battery:fraction",
+			anomaly:  AnomalyRecord{AnomalyType: "predicted_battery_violation", Value: 75, ExpectedRange: "70% - 100% (battery expected below 70 by 2024-03-13T12:00:00Z)"},
+			value:    0.75,
+			expected: "0.7 - 1 fraction (battery expected below 0.7 fraction by 2024-03-13T12:00:00Z)",
+		},
+		{
+			name:     "unknown type",
+			units:    "si",
+			anomaly:  AnomalyRecord{AnomalyType: "unknown", Value: 1, ExpectedRange: "n/a"},
+			value:    1,
+			expected: "n/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units, err := ParseUnits(tt.units)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := tt.anomaly
+			units.ConvertAnomaly(&a)
+			if math.Abs(float64(a.Value-tt.value)) > 1e-3 {
+				t.Errorf("got value %g, want %g", a.Value, tt.value)
+			}
+			if a.ExpectedRange != tt.expected {
+				t.Errorf("got expected range %q, want %q", a.ExpectedRange, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package realtime
+
+import (
+	"fmt"
+
+	"telemetry-api/internal/models"
+)
+
+// Filter selects which events a subscription receives. Empty fields match
+// everything. Parameters projects telemetry and selects anomalies by the
+// parameter they were raised on; HasAnomaly and Predicates apply to telemetry
+// and AnomalyTypes and MinSeverity to anomalies. Units picks the units values
+// are delivered in, as the units query parameter of the REST endpoints.
+type Filter struct {
+	Subsystems   []uint16 `json:"subsystems,omitempty"`
+	Parameters   []string `json:"parameters,omitempty"`
+	Events       []string `json:"events,omitempty"`
+	MinSeverity  string   `json:"min_severity,omitempty"`
+	HasAnomaly   *bool    `json:"has_anomaly,omitempty"`
+	AnomalyTypes []string `json:"anomaly_types,omitempty"`
+	Units        string   `json:"units,omitempty"`
+
+	// Predicates are set by the SSE handlers from the query string, with
+	// values in the stored units.
+	Predicates []models.ValuePredicate `json:"-"`
+
+	units models.Units // parsed from Units by Validate
+}
+
+// Validate checks the filter and must be called before it is used.
+func (f *Filter) Validate() error {
+	for _, p := range f.Parameters {
+		if !models.IsParameter(p) {
+			return fmt.Errorf("unknown parameter %q", p)
+		}
+	}
+	for _, e := range f.Events {
+		if e != EventTelemetry && e != EventAnomaly {
+			return fmt.Errorf("unknown event type %q", e)
+		}
+	}
+	for _, t := range f.AnomalyTypes {
+		if models.AnomalyParameter(t) == "" {
+			return fmt.Errorf("unknown anomaly type %q", t)
+		}
+	}
+	switch f.MinSeverity {
+	case "", models.SeverityWarning, models.SeverityCritical:
+	default:
+		return fmt.Errorf("unknown severity %q", f.MinSeverity)
+	}
+
+	units, err := models.ParseUnits(f.Units)
+	if err != nil {
+		return err
+	}
+	f.units = units
+	return nil
+}
+
+func (f Filter) Matches(event Event) bool {
+	if len(f.Events) > 0 && !contains(f.Events, event.Type) {
+		return false
+	}
+
+	switch event.Type {
+	case EventTelemetry:
+		record := event.Telemetry
+		if !f.matchesSubsystem(record.SubsystemID) {
+			return false
+		}
+		if f.HasAnomaly != nil && record.HasAnomaly != *f.HasAnomaly {
+			return false
+		}
+		for _, p := range f.Predicates {
+			if !p.Matches(*record) {
+				return false
+			}
+		}
+		return true
+	case EventAnomaly:
+		if !f.matchesSubsystem(event.Anomaly.SubsystemID) {
+			return false
+		}
+		if len(f.Parameters) > 0 && !contains(f.Parameters, event.Anomaly.Parameter()) {
+			return false
+		}
+		if len(f.AnomalyTypes) > 0 && !contains(f.AnomalyTypes, event.Anomaly.AnomalyType) {
+			return false
+		}
+		return models.SeverityRank(event.Anomaly.Severity()) >= models.SeverityRank(f.MinSeverity)
+	}
+	return false
+}
+
+// Payload shapes the event data for this subscription, converting it to the
+// requested units and projecting telemetry down to the requested parameters.
+// Events are shared between subscribers, so they are converted as copies.
+func (f Filter) Payload(event Event) interface{} {
+	if event.Type == EventAnomaly {
+		anomaly := *event.Anomaly
+		f.units.ConvertAnomaly(&anomaly)
+		return struct {
+			models.AnomalyRecord
+			Severity string `json:"severity"`
+		}{anomaly, event.Anomaly.Severity()}
+	}
+	record := *event.Telemetry
+	f.units.ConvertRecord(&record)
+	if len(f.Parameters) == 0 {
+		return &record
+	}
+	return record.Project(f.Parameters)
+}
+
+func (f Filter) matchesSubsystem(id uint16) bool {
+	if len(f.Subsystems) == 0 {
+		return true
+	}
+	for _, s := range f.Subsystems {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
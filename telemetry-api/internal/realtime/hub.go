@@ -0,0 +1,282 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 4096
+	sendBufferSize = 64
+
+	// DefaultSubscription is installed on every new connection so clients
+	// that never send a subscribe message keep receiving all telemetry.
+	DefaultSubscription = "default"
+)
+
+// ClientMessage is a control message sent by a WebSocket client.
+type ClientMessage struct {
+	Type   string `json:"type"` // 'subscribe' or 'unsubscribe'
+	ID     string `json:"id"`
+	Filter Filter `json:"filter"`
+}
+
+// ServerMessage wraps everything the hub writes to a client.
+type ServerMessage struct {
+	Type         string      `json:"type"`
+	Subscription string      `json:"subscription,omitempty"`
+	Data         interface{} `json:"data,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// Hub fans listener events out to WebSocket clients according to each
+// client's subscriptions.
+type Hub struct {
+	listener *Listener
+
+	register   chan *client
+	unregister chan *client
+	clients    map[*client]struct{}
+	done       chan struct{} // closed when Run returns
+}
+
+func NewHub(listener *Listener) *Hub {
+	return &Hub{
+		listener:   listener,
+		register:   make(chan *client),
+		unregister: make(chan *client),
+		clients:    make(map[*client]struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run distributes events until ctx is cancelled or the listener closes. Once
+// it returns, clients are disconnected and new connections are refused.
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	sub, unsubscribe := h.listener.Subscribe(256)
+	defer unsubscribe()
+
+	defer func() {
+		for c := range h.clients {
+			c.close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-h.register:
+			h.clients[c] = struct{}{}
+		case c := <-h.unregister:
+			delete(h.clients, c)
+			c.close()
+		case <-sub.Lagged:
+			// Clients are live views with no resume point, so a gap is
+			// logged rather than replayed.
+			log.Println("Websocket hub fell behind; events were dropped")
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			for c := range h.clients {
+				if !c.deliver(event) {
+					log.Printf("Evicting slow websocket client %s", c.conn.RemoteAddr())
+					delete(h.clients, c)
+					c.close()
+				}
+			}
+		}
+	}
+}
+
+// ServeWS runs a client connection until it disconnects or is evicted.
+func (h *Hub) ServeWS(conn *websocket.Conn) {
+	c := &client{
+		conn: conn,
+		send: make(chan []byte, sendBufferSize),
+		subscriptions: map[string]Filter{
+			DefaultSubscription: {Events: []string{EventTelemetry}},
+		},
+	}
+
+	select {
+	case h.register <- c:
+	case <-h.done:
+		// The hub has stopped; send the close frame and hang up.
+		c.close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.writePump()
+		close(done)
+	}()
+
+	c.readPump()
+	select {
+	case h.unregister <- c:
+	case <-h.done:
+		c.close()
+	}
+	<-done
+}
+
+type client struct {
+	conn *websocket.Conn
+	send chan []byte
+
+	mu            sync.Mutex
+	closed        bool
+	subscriptions map[string]Filter
+}
+
+// deliver queues the event for every matching subscription. It returns false
+// if the client's buffer is full.
+func (c *client) deliver(event Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, filter := range c.subscriptions {
+		if !filter.Matches(event) {
+			continue
+		}
+		msg, err := json.Marshal(ServerMessage{
+			Type:         event.Type,
+			Subscription: id,
+			Data:         filter.Payload(event),
+		})
+		if err != nil {
+			log.Printf("Error encoding websocket message: %v", err)
+			continue
+		}
+		if !c.enqueueLocked(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *client) reply(msg ServerMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error encoding websocket message: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.enqueueLocked(data)
+	c.mu.Unlock()
+}
+
+func (c *client) enqueueLocked(msg []byte) bool {
+	if c.closed {
+		return true
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+func (c *client) readPump() {
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Error reading from websocket: %v", err)
+			}
+			return
+		}
+
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reply(ServerMessage{Type: "error", Error: "invalid message"})
+			continue
+		}
+		c.handle(msg)
+	}
+}
+
+func (c *client) handle(msg ClientMessage) {
+	if msg.ID == "" {
+		c.reply(ServerMessage{Type: "error", Error: "subscription id is required"})
+		return
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		if err := msg.Filter.Validate(); err != nil {
+			c.reply(ServerMessage{Type: "error", Subscription: msg.ID, Error: err.Error()})
+			return
+		}
+		c.mu.Lock()
+		c.subscriptions[msg.ID] = msg.Filter
+		c.mu.Unlock()
+		c.reply(ServerMessage{Type: "subscribed", Subscription: msg.ID, Data: msg.Filter})
+	case "unsubscribe":
+		c.mu.Lock()
+		delete(c.subscriptions, msg.ID)
+		c.mu.Unlock()
+		c.reply(ServerMessage{Type: "unsubscribed", Subscription: msg.ID})
+	default:
+		c.reply(ServerMessage{Type: "error", Subscription: msg.ID, Error: "unknown message type " + msg.Type})
+	}
+}
+
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// The hub closed the channel, either on eviction or shutdown.
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "connection closed by server"))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
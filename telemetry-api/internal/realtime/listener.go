@@ -0,0 +1,199 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"telemetry-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Notification channels published by telemetry-ingest. These must match the
+// channel names in telemetry-ingest/internal/database.
+const (
+	TelemetryChannel = "telemetry_records"
+	AnomalyChannel   = "telemetry_anomalies"
+)
+
+const (
+	EventTelemetry = "telemetry"
+	EventAnomaly   = "anomaly"
+)
+
+// Event is a single record pushed by the ingest service.
+type Event struct {
+	Type      string
+	Telemetry *models.TelemetryRecord
+	Anomaly   *models.AnomalyRecord
+}
+
+func (e Event) ID() int64 {
+	if e.Anomaly != nil {
+		return e.Anomaly.ID
+	}
+	return e.Telemetry.ID
+}
+
+// Cursor orders events by when they were stored, which is how streams
+// resume.
+func (e Event) Cursor() models.Cursor {
+	if e.Anomaly != nil {
+		return models.Cursor{Timestamp: e.Anomaly.CreatedAt, ID: e.Anomaly.ID}
+	}
+	return models.Cursor{Timestamp: e.Telemetry.CreatedAt, ID: e.Telemetry.ID}
+}
+
+func (e Event) Timestamp() time.Time {
+	if e.Anomaly != nil {
+		return e.Anomaly.Timestamp
+	}
+	return e.Telemetry.Timestamp
+}
+
+// Listener holds one LISTEN connection and fans every notification out to
+// its subscribers, so database load does not grow with the client count.
+type Listener struct {
+	listener *pq.Listener
+
+	mu          sync.RWMutex
+	subscribers map[chan Event]chan struct{} // events to lagged
+}
+
+// Subscription receives every event published after it was taken. Events
+// are dropped rather than stall the other subscribers when the buffer is
+// full, and Lagged then receives so the subscriber can catch up some other
+// way, such as from the database.
+type Subscription struct {
+	Events <-chan Event
+	Lagged <-chan struct{}
+}
+
+func NewListener(connStr string) (*Listener, error) {
+	l := pq.NewListener(connStr, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Notification listener error: %v", err)
+		}
+	})
+
+	for _, channel := range []string{TelemetryChannel, AnomalyChannel} {
+		if err := l.Listen(channel); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	return &Listener{
+		listener:    l,
+		subscribers: make(map[chan Event]chan struct{}),
+	}, nil
+}
+
+// Subscribe registers a new subscriber. The returned function must be called
+// to release the subscription.
+func (l *Listener) Subscribe(buffer int) (Subscription, func()) {
+	ch := make(chan Event, buffer)
+	lagged := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	l.subscribers[ch] = lagged
+	l.mu.Unlock()
+
+	return Subscription{Events: ch, Lagged: lagged}, func() {
+		l.mu.Lock()
+		if _, ok := l.subscribers[ch]; ok {
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Run dispatches notifications until ctx is cancelled.
+func (l *Listener) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-l.listener.Notify:
+			if !ok {
+				// Closed by Close.
+				return
+			}
+			if n == nil {
+				// pq sends nil after re-establishing a dropped connection;
+				// anything published in between is lost.
+				log.Println("Notification listener reconnected")
+				continue
+			}
+
+			event, err := decodeNotification(n)
+			if err != nil {
+				log.Printf("Error decoding notification on %s: %v", n.Channel, err)
+				continue
+			}
+
+			l.publish(event)
+		case <-time.After(90 * time.Second):
+			go l.listener.Ping()
+		}
+	}
+}
+
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	for ch := range l.subscribers {
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+	l.mu.Unlock()
+
+	return l.listener.Close()
+}
+
+func (l *Listener) publish(event Event) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for ch, lagged := range l.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Never let one slow subscriber stall the fan-out.
+			select {
+			case lagged <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func decodeNotification(n *pq.Notification) (Event, error) {
+	// created_at is not part of the records' JSON form.
+	var stored struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal([]byte(n.Extra), &stored); err != nil {
+		return Event{}, err
+	}
+
+	switch n.Channel {
+	case TelemetryChannel:
+		var record models.TelemetryRecord
+		if err := json.Unmarshal([]byte(n.Extra), &record); err != nil {
+			return Event{}, err
+		}
+		record.CreatedAt = stored.CreatedAt
+		return Event{Type: EventTelemetry, Telemetry: &record}, nil
+	default:
+		var record models.AnomalyRecord
+		if err := json.Unmarshal([]byte(n.Extra), &record); err != nil {
+			return Event{}, err
+		}
+		record.CreatedAt = stored.CreatedAt
+		return Event{Type: EventAnomaly, Anomaly: &record}, nil
+	}
+}
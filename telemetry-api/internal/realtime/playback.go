@@ -0,0 +1,325 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"telemetry-api/internal/database"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+const (
+	playbackChunk    = 5 * time.Minute
+	maxPlaybackSpeed = 1000
+)
+
+// Locals keys under which the playback window is passed in from the HTTP
+// handler that parses it.
+const (
+	PlaybackStartLocal = "playback_start"
+	PlaybackEndLocal   = "playback_end"
+)
+
+// PlaybackControl is a control message sent by a playback client.
+type PlaybackControl struct {
+	Type  string    `json:"type"` // 'pause', 'resume', 'seek' or 'speed'
+	Time  time.Time `json:"time"`
+	Speed float64   `json:"speed"`
+}
+
+// PlaybackState is reported to the client after every control message.
+type PlaybackState struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Position time.Time `json:"position"`
+	Speed    float64   `json:"speed"`
+	Paused   bool      `json:"paused"`
+}
+
+// Playback replays stored telemetry and anomalies over a WebSocket with their
+// original relative timing, using the same message format as the live hub.
+type Playback struct {
+	db *database.Database
+}
+
+func NewPlayback(db *database.Database) *Playback {
+	return &Playback{db: db}
+}
+
+// Serve plays back the window [start_time, end_time), which
+// handlers.PlaybackWindow parses from the upgrade request, and takes optional
+// speed, subsystem_id and units query parameters. Like every other range in
+// the API the window is half-open: records at exactly end_time are not
+// played.
+func (p *Playback) Serve(conn *websocket.Conn) {
+	defer conn.Close()
+
+	s, err := newPlaybackSession(conn, p.db)
+	if err != nil {
+		conn.WriteJSON(ServerMessage{Type: "error", Error: err.Error()})
+		conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()))
+		return
+	}
+
+	controls := make(chan PlaybackControl)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.readControls(controls, stop)
+	}()
+
+	s.run(controls, done)
+
+	// The connection is recycled once Serve returns, so the reader must be
+	// finished with it first.
+	close(stop)
+	conn.Close()
+	<-done
+}
+
+type playbackSession struct {
+	conn   *websocket.Conn
+	db     *database.Database
+	filter Filter
+
+	start, end time.Time
+	speed      float64
+	paused     bool
+
+	// The playback clock: data time advances from anchorData at speed times
+	// wall-clock time since anchorWall.
+	anchorData time.Time
+	anchorWall time.Time
+
+	queue       []Event
+	loadedUntil time.Time
+}
+
+func newPlaybackSession(conn *websocket.Conn, db *database.Database) (*playbackSession, error) {
+	start, _ := conn.Locals(PlaybackStartLocal).(time.Time)
+	end, _ := conn.Locals(PlaybackEndLocal).(time.Time)
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("start_time and end_time are required")
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end_time must be after start_time")
+	}
+
+	speed, err := strconv.ParseFloat(conn.Query("speed", "1"), 64)
+	if err != nil || !validSpeed(speed) {
+		return nil, fmt.Errorf("speed must be between 0 and %d", maxPlaybackSpeed)
+	}
+
+	var filter Filter
+	for _, raw := range strings.Split(conn.Query("subsystem_id"), ",") {
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subsystem_id")
+		}
+		filter.Subsystems = append(filter.Subsystems, uint16(id))
+	}
+	filter.Units = conn.Query("units")
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &playbackSession{
+		conn:        conn,
+		db:          db,
+		filter:      filter,
+		start:       start,
+		end:         end,
+		speed:       speed,
+		anchorData:  start,
+		anchorWall:  time.Now(),
+		loadedUntil: start,
+	}, nil
+}
+
+func (s *playbackSession) run(controls <-chan PlaybackControl, done <-chan struct{}) {
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+
+	if !s.write(ServerMessage{Type: "playback", Data: s.state()}) {
+		return
+	}
+
+	for {
+		if !s.paused && len(s.queue) == 0 {
+			if !s.loadedUntil.Before(s.end) {
+				s.anchorData, s.paused = s.end, true
+				if !s.write(ServerMessage{Type: "end", Data: s.state()}) {
+					return
+				}
+				continue
+			}
+			if err := s.load(); err != nil {
+				log.Printf("Error loading playback data: %v", err)
+				s.write(ServerMessage{Type: "error", Error: "Failed to load playback data"})
+				return
+			}
+			continue
+		}
+
+		var timer *time.Timer
+		var next <-chan time.Time
+		if !s.paused {
+			wait := time.Duration(float64(s.queue[0].Timestamp().Sub(s.position())) / s.speed)
+			timer = time.NewTimer(wait)
+			next = timer.C
+		}
+
+		if !s.step(controls, done, next, ping.C) {
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// step waits for the next control message, due event or keepalive. It
+// returns false once the connection is gone.
+func (s *playbackSession) step(controls <-chan PlaybackControl, done <-chan struct{}, next, ping <-chan time.Time) bool {
+	select {
+	case <-done:
+		return false
+	case ctrl := <-controls:
+		if err := s.apply(ctrl); err != nil {
+			return s.write(ServerMessage{Type: "error", Error: err.Error()})
+		}
+		return s.write(ServerMessage{Type: "playback", Data: s.state()})
+	case <-next:
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		return s.write(ServerMessage{Type: event.Type, Subscription: "playback", Data: s.filter.Payload(event)})
+	case <-ping:
+		s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return s.conn.WriteMessage(websocket.PingMessage, nil) == nil
+	}
+}
+
+func (s *playbackSession) apply(ctrl PlaybackControl) error {
+	switch ctrl.Type {
+	case "pause":
+		s.anchorData, s.paused = s.position(), true
+	case "resume":
+		s.anchorWall, s.paused = time.Now(), false
+	case "seek":
+		if ctrl.Time.Before(s.start) || ctrl.Time.After(s.end) {
+			return fmt.Errorf("seek time must be within the playback window")
+		}
+		s.anchorData, s.anchorWall = ctrl.Time, time.Now()
+		s.queue, s.loadedUntil = nil, ctrl.Time
+	case "speed":
+		if !validSpeed(ctrl.Speed) {
+			return fmt.Errorf("speed must be between 0 and %d", maxPlaybackSpeed)
+		}
+		s.anchorData, s.anchorWall = s.position(), time.Now()
+		s.speed = ctrl.Speed
+	case "":
+		return fmt.Errorf("invalid control message")
+	default:
+		return fmt.Errorf("unknown control %q", ctrl.Type)
+	}
+	return nil
+}
+
+func (s *playbackSession) position() time.Time {
+	if s.paused {
+		return s.anchorData
+	}
+	elapsed := time.Duration(float64(time.Since(s.anchorWall)) * s.speed)
+	return s.anchorData.Add(elapsed)
+}
+
+func (s *playbackSession) state() PlaybackState {
+	return PlaybackState{
+		Start:    s.start,
+		End:      s.end,
+		Position: s.position(),
+		Speed:    s.speed,
+		Paused:   s.paused,
+	}
+}
+
+// load reads the next chunk of the window and merges telemetry and anomalies
+// into a single timeline.
+func (s *playbackSession) load() error {
+	from := s.loadedUntil
+	to := from.Add(playbackChunk)
+	if to.After(s.end) {
+		to = s.end
+	}
+
+	telemetry, err := s.db.GetTelemetryBetween(from, to, s.filter.Subsystems)
+	if err != nil {
+		return err
+	}
+	anomalies, err := s.db.GetAnomaliesBetween(from, to, s.filter.Subsystems)
+	if err != nil {
+		return err
+	}
+
+	for i := range telemetry {
+		s.queue = append(s.queue, Event{Type: EventTelemetry, Telemetry: &telemetry[i]})
+	}
+	for i := range anomalies {
+		s.queue = append(s.queue, Event{Type: EventAnomaly, Anomaly: &anomalies[i]})
+	}
+	sort.SliceStable(s.queue, func(i, j int) bool {
+		return s.queue[i].Timestamp().Before(s.queue[j].Timestamp())
+	})
+
+	s.loadedUntil = to
+	return nil
+}
+
+func (s *playbackSession) write(msg ServerMessage) bool {
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		return false
+	}
+	return true
+}
+
+func (s *playbackSession) readControls(controls chan<- PlaybackControl, stop <-chan struct{}) {
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var ctrl PlaybackControl
+		if err := json.Unmarshal(data, &ctrl); err != nil {
+			ctrl = PlaybackControl{}
+		}
+
+		select {
+		case controls <- ctrl:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func validSpeed(speed float64) bool {
+	return speed > 0 && speed <= maxPlaybackSpeed
+}
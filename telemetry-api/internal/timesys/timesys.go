@@ -0,0 +1,163 @@
+// Package timesys converts between UTC and the time systems used in
+// spacecraft operations: International Atomic Time (TAI), GPS time and
+// Mission Elapsed Time (MET).
+package timesys
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type System string
+
+const (
+	UTC System = "UTC"
+	TAI System = "TAI"
+	GPS System = "GPS"
+	MET System = "MET"
+)
+
+// Layout formats TAI and GPS timestamps. They carry no zone designator
+// because they are not UTC offsets.
+const Layout = "2006-01-02T15:04:05.999999999"
+
+// GPS time runs a constant 19 s behind TAI.
+const gpsOffset = 19 * time.Second
+
+// leapSeconds lists TAI-UTC from each UTC instant on, per IERS Bulletin C.
+// Times before 1972 use the first entry.
+var leapSeconds = []struct {
+	from   time.Time
+	offset time.Duration
+}{
+	{date(1972, 1), 10 * time.Second},
+	{date(1972, 7), 11 * time.Second},
+	{date(1973, 1), 12 * time.Second},
+	{date(1974, 1), 13 * time.Second},
+	{date(1975, 1), 14 * time.Second},
+	{date(1976, 1), 15 * time.Second},
+	{date(1977, 1), 16 * time.Second},
+	{date(1978, 1), 17 * time.Second},
+	{date(1979, 1), 18 * time.Second},
+	{date(1980, 1), 19 * time.Second},
+	{date(1981, 7), 20 * time.Second},
+	{date(1982, 7), 21 * time.Second},
+	{date(1983, 7), 22 * time.Second},
+	{date(1985, 7), 23 * time.Second},
+	{date(1988, 1), 24 * time.Second},
+	{date(1990, 1), 25 * time.Second},
+	{date(1991, 1), 26 * time.Second},
+	{date(1992, 7), 27 * time.Second},
+	{date(1993, 7), 28 * time.Second},
+	{date(1994, 7), 29 * time.Second},
+	{date(1996, 1), 30 * time.Second},
+	{date(1997, 7), 31 * time.Second},
+	{date(1999, 1), 32 * time.Second},
+	{date(2006, 1), 33 * time.Second},
+	{date(2009, 1), 34 * time.Second},
+	{date(2012, 7), 35 * time.Second},
+	{date(2015, 7), 36 * time.Second},
+	{date(2017, 1), 37 * time.Second},
+}
+
+func date(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Parse reads a time system name, case-insensitively. Empty means UTC.
+func Parse(name string) (System, error) {
+	switch System(strings.ToUpper(name)) {
+	case "", UTC:
+		return UTC, nil
+	case TAI:
+		return TAI, nil
+	case GPS:
+		return GPS, nil
+	case MET:
+		return MET, nil
+	}
+	return "", fmt.Errorf("time_system must be UTC, TAI, GPS or MET")
+}
+
+// LeapOffset returns TAI-UTC at the UTC instant t.
+func LeapOffset(t time.Time) time.Duration {
+	offset := leapSeconds[0].offset
+	for _, leap := range leapSeconds {
+		if t.Before(leap.from) {
+			break
+		}
+		offset = leap.offset
+	}
+	return offset
+}
+
+// Converter renders UTC instants in one time system and reads them back.
+// Epoch is the mission's UTC start, needed only for MET.
+type Converter struct {
+	System System
+	Epoch  time.Time
+}
+
+func NewConverter(system System, epoch time.Time) (Converter, error) {
+	if system == MET && epoch.IsZero() {
+		return Converter{}, fmt.Errorf("time_system MET needs a mission epoch, which is not configured")
+	}
+	return Converter{System: system, Epoch: epoch}, nil
+}
+
+func (c Converter) IsUTC() bool {
+	return c.System == "" || c.System == UTC
+}
+
+// Shift returns the UTC instant t as a wall-clock reading in TAI or GPS.
+func (c Converter) Shift(t time.Time) time.Time {
+	t = t.UTC()
+	switch c.System {
+	case TAI:
+		return t.Add(LeapOffset(t))
+	case GPS:
+		return t.Add(LeapOffset(t) - gpsOffset)
+	}
+	return t
+}
+
+// Unshift is the inverse of Shift: it reads a TAI or GPS wall-clock time as
+// a UTC instant. Readings inside an inserted leap second map to its end.
+func (c Converter) Unshift(t time.Time) time.Time {
+	tai := t.UTC()
+	switch c.System {
+	case TAI:
+	case GPS:
+		tai = tai.Add(gpsOffset)
+	default:
+		return tai
+	}
+	// TAI-UTC is defined on the UTC instant, so estimate that first.
+	estimate := tai.Add(-LeapOffset(tai))
+	return tai.Add(-LeapOffset(estimate))
+}
+
+// Elapsed returns the SI seconds between the mission epoch and t, counting
+// leap seconds.
+func (c Converter) Elapsed(t time.Time) float64 {
+	return (t.Sub(c.Epoch) + LeapOffset(t) - LeapOffset(c.Epoch)).Seconds()
+}
+
+// FromElapsed returns the UTC instant seconds after the mission epoch.
+func (c Converter) FromElapsed(seconds float64) time.Time {
+	epoch := Converter{System: TAI}.Shift(c.Epoch)
+	return Converter{System: TAI}.Unshift(epoch.Add(time.Duration(seconds * float64(time.Second))))
+}
+
+// Format renders the UTC instant t: UTC as a time.Time, TAI and GPS as
+// strings in Layout, and MET as seconds since the epoch.
+func (c Converter) Format(t time.Time) interface{} {
+	switch c.System {
+	case TAI, GPS:
+		return c.Shift(t).Format(Layout)
+	case MET:
+		return c.Elapsed(t)
+	}
+	return t.UTC()
+}
@@ -0,0 +1,131 @@
+package timesys
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func utc(year int, month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+}
+
+func TestLeapOffset(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want time.Duration
+	}{
+		{at: utc(1965, time.June, 1, 0, 0, 0), want: 10 * time.Second},
+		{at: utc(1972, time.January, 1, 0, 0, 0), want: 10 * time.Second},
+		{at: utc(1972, time.June, 30, 23, 59, 59), want: 10 * time.Second},
+		{at: utc(1972, time.July, 1, 0, 0, 0), want: 11 * time.Second},
+		{at: utc(1980, time.January, 6, 0, 0, 0), want: 19 * time.Second},
+		{at: utc(2016, time.December, 31, 23, 59, 59), want: 36 * time.Second},
+		{at: utc(2017, time.January, 1, 0, 0, 0), want: 37 * time.Second},
+		{at: utc(2030, time.January, 1, 0, 0, 0), want: 37 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := LeapOffset(tt.at); got != tt.want {
+			t.Errorf("LeapOffset(%s) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		system System
+		at     time.Time
+		want   string
+	}{
+		{system: TAI, at: utc(2024, time.March, 13, 12, 0, 0), want: "2024-03-13T12:00:37"},
+		{system: GPS, at: utc(2024, time.March, 13, 12, 0, 0), want: "2024-03-13T12:00:18"},
+		// GPS time started in step with UTC at its epoch.
+		{system: GPS, at: utc(1980, time.January, 6, 0, 0, 0), want: "1980-01-06T00:00:00"},
+		{system: TAI, at: utc(2016, time.December, 31, 23, 59, 59), want: "2017-01-01T00:00:35"},
+		{system: TAI, at: utc(2017, time.January, 1, 0, 0, 0), want: "2017-01-01T00:00:37"},
+	}
+
+	for _, tt := range tests {
+		c := Converter{System: tt.system}
+		shifted := c.Shift(tt.at)
+		if got := shifted.Format(Layout); got != tt.want {
+			t.Errorf("%s Shift(%s) = %s, want %s", tt.system, tt.at, got, tt.want)
+		}
+		if back := c.Unshift(shifted); !back.Equal(tt.at) {
+			t.Errorf("%s Unshift(%s) = %s, want %s", tt.system, shifted, back, tt.at)
+		}
+	}
+}
+
+func TestUnshiftInsertedLeapSecond(t *testing.T) {
+	// 2017-01-01T00:00:36 TAI is 2016-12-31T23:59:60 UTC, which maps to the
+	// end of the leap second.
+	c := Converter{System: TAI}
+	reading := utc(2017, time.January, 1, 0, 0, 36)
+	if got, want := c.Unshift(reading), utc(2017, time.January, 1, 0, 0, 0); !got.Equal(want) {
+		t.Errorf("Unshift(%s) = %s, want %s", reading, got, want)
+	}
+}
+
+func TestMissionElapsedTime(t *testing.T) {
+	epoch := utc(2016, time.December, 31, 0, 0, 0)
+	c, err := NewConverter(MET, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at      time.Time
+		elapsed float64
+	}{
+		{at: epoch, elapsed: 0},
+		{at: utc(2016, time.December, 31, 1, 0, 0), elapsed: 3600},
+		// A day spanning the leap second at the end of 2016 is 86401 s long.
+		{at: utc(2017, time.January, 1, 0, 0, 0), elapsed: 86401},
+		{at: utc(2017, time.January, 2, 0, 0, 0), elapsed: 2*86400 + 1},
+		{at: utc(2016, time.December, 30, 0, 0, 0), elapsed: -86400},
+	}
+
+	for _, tt := range tests {
+		if got := c.Elapsed(tt.at); math.Abs(got-tt.elapsed) > 1e-9 {
+			t.Errorf("Elapsed(%s) = %g, want %g", tt.at, got, tt.elapsed)
+		}
+		if got := c.FromElapsed(tt.elapsed); !got.Equal(tt.at) {
+			t.Errorf("FromElapsed(%g) = %s, want %s", tt.elapsed, got, tt.at)
+		}
+		if got := c.Format(tt.at); got != tt.elapsed {
+			t.Errorf("Format(%s) = %v, want %g", tt.at, got, tt.elapsed)
+		}
+	}
+
+	if _, err := NewConverter(MET, time.Time{}); err == nil {
+		t.Error("expected an error for MET without an epoch")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    System
+		wantErr bool
+	}{
+		{name: "", want: UTC},
+		{name: "utc", want: UTC},
+		{name: "TAI", want: TAI},
+		{name: "gps", want: GPS},
+		{name: "Met", want: MET},
+		{name: "TT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
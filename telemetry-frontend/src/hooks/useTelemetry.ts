@@ -74,8 +74,15 @@ export const useTelemetry = () => {
   useEffect(() => {
     const fetchInitialData = async () => {
       try {
-        const data = await telemetryService.getCurrentTelemetry();
-        handleTelemetryUpdate(data);
+        const records = await telemetryService.getCurrentTelemetry();
+        const latest = records.reduce<TelemetryRecord | null>(
+          (newest, record) =>
+            !newest || record.timestamp > newest.timestamp ? record : newest,
+          null
+        );
+        if (latest) {
+          handleTelemetryUpdate(latest);
+        }
       } catch (err) {
         setError(
           err instanceof Error ? err.message : "Failed to fetch telemetry data"
@@ -1,4 +1,8 @@
-import { TelemetryRecord, TelemetryResponse } from "../types/telemetry";
+import {
+  StreamMessage,
+  TelemetryRecord,
+  TelemetryResponse,
+} from "../types/telemetry";
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || "/api/v1";
 const WS_URL = process.env.REACT_APP_WS_URL || "ws://localhost:3000/ws";
@@ -8,7 +12,7 @@ export class TelemetryService {
   private reconnectAttempts = 0;
   private maxReconnectAttempts = 5;
 
-  async getCurrentTelemetry(): Promise<TelemetryRecord> {
+  async getCurrentTelemetry(): Promise<TelemetryRecord[]> {
     const response = await fetch(`${API_BASE_URL}/telemetry/current`);
     if (!response.ok) {
       throw new Error("Failed to fetch current telemetry");
@@ -58,8 +62,10 @@ export class TelemetryService {
     this.ws = new WebSocket(WS_URL);
 
     this.ws.onmessage = (event) => {
-      const data = JSON.parse(event.data);
-      onMessage(data);
+      const message: StreamMessage = JSON.parse(event.data);
+      if (message.type === "telemetry") {
+        onMessage(message.data as TelemetryRecord);
+      }
     };
 
     this.ws.onclose = () => {
@@ -1,4 +1,5 @@
 export interface TelemetryRecord {
+  id: number;
   timestamp: string;
   subsystem_id: number;
   temperature: number;
@@ -9,6 +10,7 @@ export interface TelemetryRecord {
 }
 
 export interface AnomalyRecord {
+  id: number;
   timestamp: string;
   subsystem_id: number;
   anomaly_type: string;
@@ -16,6 +18,13 @@ export interface AnomalyRecord {
   expected_range: string;
 }
 
+export interface StreamMessage {
+  type: "telemetry" | "anomaly" | "subscribed" | "unsubscribed" | "error";
+  subscription?: string;
+  data?: TelemetryRecord | AnomalyRecord;
+  error?: string;
+}
+
 export interface TelemetryResponse {
   data: TelemetryRecord[] | AnomalyRecord[];
   metadata: {
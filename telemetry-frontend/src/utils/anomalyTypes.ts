@@ -4,6 +4,10 @@ export const anomalyTypeDisplayNames: Record<string, string> = {
   low_battery: "Low Battery",
   low_altitude: "Low Altitude",
   weak_signal: "Weak Signal",
+  predicted_temperature_violation: "Predicted Temperature Violation",
+  predicted_battery_violation: "Predicted Battery Violation",
+  predicted_altitude_violation: "Predicted Altitude Violation",
+  predicted_signal_violation: "Predicted Signal Violation",
 };
 
 export const getAnomalyDisplayName = (anomalyType: string): string => {
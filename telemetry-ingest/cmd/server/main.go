@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"telemetry-ingest/internal/database"
+	"telemetry-ingest/internal/forecast"
 	"telemetry-ingest/internal/models"
 	"time"
 )
@@ -29,6 +30,21 @@ func main() {
 	}
 	defer db.Close()
 
+	// Trend forecasting is off unless a horizon is configured.
+	if value := os.Getenv("FORECAST_HORIZON"); value != "" {
+		horizon, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid FORECAST_HORIZON: %v", err)
+		}
+		lookback := 24 * time.Hour
+		if value := os.Getenv("FORECAST_LOOKBACK"); value != "" {
+			if lookback, err = time.ParseDuration(value); err != nil {
+				log.Fatalf("Invalid FORECAST_LOOKBACK: %v", err)
+			}
+		}
+		go forecast.NewWatcher(db, horizon, lookback).Run()
+	}
+
 	addr, err := net.ResolveUDPAddr("udp", UDP_PORT)
 	if err != nil {
 		log.Fatalf("Failed to resolve UDP address: %v", err)
@@ -4,10 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"telemetry-ingest/internal/models"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// Notification channels the API service LISTENs on. Every stored row is
+// published as a JSON payload matching the API's record models.
+const (
+	TelemetryChannel = "telemetry_records"
+	AnomalyChannel   = "telemetry_anomalies"
+)
+
 type Database struct {
 	db *sql.DB
 }
@@ -30,11 +38,27 @@ func NewDatabase(host string, port int, user, password, dbname string) (*Databas
 
 func (d *Database) StoreTelemetry(record *models.TelemetryRecord) error {
 	_, err := d.db.Exec(`
-		INSERT INTO telemetry (
-			timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		WITH inserted AS (
+			INSERT INTO telemetry (
+				timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, timestamp, subsystem_id, temperature, battery, altitude, signal, has_anomaly
+		)
+		SELECT pg_notify($8, json_build_object(
+			'id', id,
+			'created_at', created_at,
+			'timestamp', timestamp,
+			'subsystem_id', subsystem_id,
+			'temperature', temperature,
+			'battery', battery,
+			'altitude', altitude,
+			'signal', signal,
+			'has_anomaly', has_anomaly
+		)::text)
+		FROM inserted`,
 		record.Timestamp, record.SubsystemID, record.Temperature,
 		record.Battery, record.Altitude, record.Signal, record.HasAnomaly,
+		TelemetryChannel,
 	)
 	if err != nil {
 		return fmt.Errorf("error storing telemetry: %v", err)
@@ -42,6 +66,26 @@ func (d *Database) StoreTelemetry(record *models.TelemetryRecord) error {
 	return nil
 }
 
+// insertAnomaly stores one anomaly and publishes it on the channel given as
+// $6.
+const insertAnomaly = `
+	WITH inserted AS (
+		INSERT INTO anomalies (
+			timestamp, subsystem_id, anomaly_type, value, expected_range
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, timestamp, subsystem_id, anomaly_type, value, expected_range
+	)
+	SELECT pg_notify($6, json_build_object(
+		'id', id,
+		'created_at', created_at,
+		'timestamp', timestamp,
+		'subsystem_id', subsystem_id,
+		'anomaly_type', anomaly_type,
+		'value', value,
+		'expected_range', expected_range
+	)::text)
+	FROM inserted`
+
 func (d *Database) StoreAnomalies(anomalies []models.Anomaly) error {
 	if len(anomalies) == 0 {
 		return nil
@@ -52,11 +96,9 @@ func (d *Database) StoreAnomalies(anomalies []models.Anomaly) error {
 		return fmt.Errorf("error starting transaction: %v", err)
 	}
 
-	stmt, err := tx.Prepare(`
-		INSERT INTO anomalies (
-			timestamp, subsystem_id, anomaly_type, value, expected_range
-		) VALUES ($1, $2, $3, $4, $5)
-	`)
+	// Notifications sent inside the transaction are only delivered on commit,
+	// so listeners never see anomalies that were rolled back.
+	stmt, err := tx.Prepare(insertAnomaly)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("error preparing statement: %v", err)
@@ -70,6 +112,7 @@ func (d *Database) StoreAnomalies(anomalies []models.Anomaly) error {
 			anomaly.AnomalyType,
 			anomaly.Value,
 			anomaly.ExpectedRange,
+			AnomalyChannel,
 		)
 		if err != nil {
 			tx.Rollback()
@@ -84,6 +127,84 @@ func (d *Database) StoreAnomalies(anomalies []models.Anomaly) error {
 	return nil
 }
 
+// RaiseAnomalyOnce stores anomaly unless its subsystem already has an
+// anomaly of the same type at or after since, and reports whether it was
+// stored. A transaction-scoped advisory lock on the subsystem and type
+// serializes the check and insert, so concurrent ingest instances cannot
+// both raise it.
+func (d *Database) RaiseAnomalyOnce(anomaly models.Anomaly, since time.Time) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1), $2)`, anomaly.AnomalyType, int32(anomaly.SubsystemID)); err != nil {
+		return false, fmt.Errorf("error locking anomaly: %v", err)
+	}
+
+	// Read after taking the lock, so an anomaly committed by the previous
+	// holder is visible.
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM anomalies
+			WHERE subsystem_id = $1 AND anomaly_type = $2 AND timestamp >= $3
+		)`, anomaly.SubsystemID, anomaly.AnomalyType, since).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking anomalies: %v", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	_, err = tx.Exec(insertAnomaly,
+		anomaly.Timestamp,
+		anomaly.SubsystemID,
+		anomaly.AnomalyType,
+		anomaly.Value,
+		anomaly.ExpectedRange,
+		AnomalyChannel,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error storing anomaly: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return true, nil
+}
+
+// GetTrend averages parameter per subsystem over buckets of width since the
+// given time. parameter is a column name and must come from
+// models.Parameters.
+func (d *Database) GetTrend(parameter string, since time.Time, width time.Duration) (map[uint16][]models.TrendPoint, error) {
+	rows, err := d.db.Query(fmt.Sprintf(`
+		SELECT subsystem_id, time_bucket($1::interval, timestamp) AS bucket, AVG(%s)::float8
+		FROM telemetry
+		WHERE timestamp >= $2
+		GROUP BY subsystem_id, bucket
+		ORDER BY subsystem_id, bucket`, parameter),
+		fmt.Sprintf("%d seconds", int64(width.Seconds())), since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying trend: %v", err)
+	}
+	defer rows.Close()
+
+	trends := make(map[uint16][]models.TrendPoint)
+	for rows.Next() {
+		var subsystemID uint16
+		var point models.TrendPoint
+		if err := rows.Scan(&subsystemID, &point.Timestamp, &point.Value); err != nil {
+			return nil, fmt.Errorf("error scanning trend: %v", err)
+		}
+		trends[subsystemID] = append(trends[subsystemID], point)
+	}
+	return trends, rows.Err()
+}
+
 func (d *Database) Close() error {
 	return d.db.Close()
 }
@@ -0,0 +1,152 @@
+package forecast
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"telemetry-ingest/internal/database"
+	"telemetry-ingest/internal/models"
+)
+
+const (
+	checkInterval = 15 * time.Minute
+	trendPoints   = 500 // target bucket count over the lookback
+)
+
+// AnomalyType is the predicted violation type raised for parameter.
+func AnomalyType(parameter string) string {
+	return "predicted_" + parameter + "_violation"
+}
+
+// Watcher periodically fits a Theil-Sen trend to every parameter of every
+// subsystem and raises a predicted_<parameter>_violation anomaly when the
+// trend reaches either edge of the parameter's normal band within the
+// horizon. Each subsystem and parameter is raised at most once per horizon,
+// checked against the anomalies table so that restarts and other instances
+// do not repeat it.
+type Watcher struct {
+	db       *database.Database
+	horizon  time.Duration
+	lookback time.Duration
+}
+
+func NewWatcher(db *database.Database, horizon, lookback time.Duration) *Watcher {
+	return &Watcher{db: db, horizon: horizon, lookback: lookback}
+}
+
+func (w *Watcher) Run() {
+	log.Printf("Forecasting limit violations within %s from a %s lookback", w.horizon, w.lookback)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now().UTC())
+		<-ticker.C
+	}
+}
+
+func (w *Watcher) check(now time.Time) {
+	width := (w.lookback / trendPoints).Truncate(time.Second)
+	if width < time.Minute {
+		width = time.Minute
+	}
+
+	for _, parameter := range models.Parameters {
+		trends, err := w.db.GetTrend(parameter, now.Add(-w.lookback), width)
+		if err != nil {
+			log.Printf("Error forecasting %s: %v", parameter, err)
+			continue
+		}
+
+		band := models.NormalBands[parameter]
+		for subsystemID, points := range trends {
+			fitted, limit, eta, ok := crossing(points, band, now, width)
+			if !ok || eta.Sub(now) > w.horizon {
+				continue
+			}
+
+			direction := "below"
+			if limit == band.Max {
+				direction = "above"
+			}
+			anomaly := models.Anomaly{
+				Timestamp:     now,
+				SubsystemID:   subsystemID,
+				AnomalyType:   AnomalyType(parameter),
+				Value:         float32(fitted),
+				ExpectedRange: fmt.Sprintf("%s (%s expected %s %g by %s)", band.ExpectedRange, parameter, direction, limit, eta.Format(time.RFC3339)),
+			}
+
+			raised, err := w.db.RaiseAnomalyOnce(anomaly, now.Add(-w.horizon))
+			if err != nil {
+				log.Printf("Error storing predicted violation: %v", err)
+				continue
+			}
+			if raised {
+				log.Printf("ALERT: %s for subsystem %d - %s", anomaly.AnomalyType, anomaly.SubsystemID, anomaly.ExpectedRange)
+			}
+		}
+	}
+}
+
+// crossing fits the bucket averages with x in hours relative to now and
+// returns the trend value now, the edge of band it is heading for and when it
+// gets there. ok is false if the trend is flat or already outside the band,
+// which Validate reports as it happens.
+func crossing(points []models.TrendPoint, band models.NormalBand, now time.Time, width time.Duration) (float64, float64, time.Time, bool) {
+	if len(points) < 3 {
+		return 0, 0, time.Time{}, false
+	}
+
+	xs := make([]float64, len(points))
+	for i, p := range points {
+		xs[i] = p.Timestamp.Add(width / 2).Sub(now).Hours()
+	}
+
+	var slopes []float64
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			if dx := xs[j] - xs[i]; dx != 0 {
+				slopes = append(slopes, (points[j].Value-points[i].Value)/dx)
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return 0, 0, time.Time{}, false
+	}
+	slope := median(slopes)
+
+	residuals := make([]float64, len(points))
+	for i, p := range points {
+		residuals[i] = p.Value - slope*xs[i]
+	}
+	fitted := median(residuals)
+	if fitted < band.Min || fitted > band.Max {
+		return fitted, 0, time.Time{}, false
+	}
+
+	var limit float64
+	switch {
+	case slope < 0:
+		limit = band.Min
+	case slope > 0:
+		limit = band.Max
+	default:
+		return fitted, 0, time.Time{}, false
+	}
+
+	hours := (limit - fitted) / slope
+	return fitted, limit, now.Add(time.Duration(hours * float64(time.Hour))), true
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
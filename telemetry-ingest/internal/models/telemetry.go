@@ -43,6 +43,31 @@ type Anomaly struct {
 	ExpectedRange string
 }
 
+// NormalBand is the range Validate accepts for a parameter and how it is
+// reported in an anomaly's expected range.
+type NormalBand struct {
+	Min           float64
+	Max           float64
+	ExpectedRange string
+}
+
+// Parameters lists the telemetry columns in storage order.
+var Parameters = []string{"temperature", "battery", "altitude", "signal"}
+
+// NormalBands matches the limits checked by the Validate methods.
+var NormalBands = map[string]NormalBand{
+	"temperature": {Min: 20, Max: 30, ExpectedRange: "20.0°C - 30.0°C"},
+	"battery":     {Min: 70, Max: 100, ExpectedRange: "70% - 100%"},
+	"altitude":    {Min: 500, Max: 550, ExpectedRange: "500km - 550km"},
+	"signal":      {Min: -60, Max: -40, ExpectedRange: "-60dB to -40dB"},
+}
+
+// TrendPoint is a bucket average of one parameter, used by the forecaster.
+type TrendPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
 func (t *TelemetryPayload) ValidateTemperature() (bool, string) {
 	if t.Temperature > 35.0 {
 		return false, "high_temperature"
@@ -0,0 +1,4 @@
+-- Dropping a continuous aggregate also removes its refresh policy
+DROP MATERIALIZED VIEW IF EXISTS telemetry_1d;
+DROP MATERIALIZED VIEW IF EXISTS telemetry_1h;
+DROP MATERIALIZED VIEW IF EXISTS telemetry_1m;
@@ -0,0 +1,128 @@
+-- Continuous aggregates at 1-minute, 1-hour and 1-day resolution. Each stores
+-- enough per-bucket state (min, max, sum, sum of squares, first, last) for the
+-- API to re-aggregate into any coarser interval, including avg and stddev.
+-- Real-time aggregation is enabled so the newest, not yet materialized
+-- buckets are still served from the raw hypertable.
+
+CREATE MATERIALIZED VIEW telemetry_1m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+    SELECT
+        time_bucket(INTERVAL '1 minute', timestamp) AS bucket,
+        subsystem_id,
+        COUNT(*) AS sample_count,
+        MIN(temperature) AS temperature_min,
+        MAX(temperature) AS temperature_max,
+        SUM(temperature::FLOAT8) AS temperature_sum,
+        SUM(temperature::FLOAT8 * temperature::FLOAT8) AS temperature_sumsq,
+        first(temperature, timestamp) AS temperature_first,
+        last(temperature, timestamp) AS temperature_last,
+        MIN(battery) AS battery_min,
+        MAX(battery) AS battery_max,
+        SUM(battery::FLOAT8) AS battery_sum,
+        SUM(battery::FLOAT8 * battery::FLOAT8) AS battery_sumsq,
+        first(battery, timestamp) AS battery_first,
+        last(battery, timestamp) AS battery_last,
+        MIN(altitude) AS altitude_min,
+        MAX(altitude) AS altitude_max,
+        SUM(altitude::FLOAT8) AS altitude_sum,
+        SUM(altitude::FLOAT8 * altitude::FLOAT8) AS altitude_sumsq,
+        first(altitude, timestamp) AS altitude_first,
+        last(altitude, timestamp) AS altitude_last,
+        MIN(signal) AS signal_min,
+        MAX(signal) AS signal_max,
+        SUM(signal::FLOAT8) AS signal_sum,
+        SUM(signal::FLOAT8 * signal::FLOAT8) AS signal_sumsq,
+        first(signal, timestamp) AS signal_first,
+        last(signal, timestamp) AS signal_last,
+        SUM(has_anomaly::INT) AS anomaly_count
+    FROM telemetry
+    GROUP BY bucket, subsystem_id
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('telemetry_1m',
+    start_offset => INTERVAL '2 hours',
+    end_offset => INTERVAL '1 minute',
+    schedule_interval => INTERVAL '1 minute'
+);
+
+CREATE MATERIALIZED VIEW telemetry_1h
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+    SELECT
+        time_bucket(INTERVAL '1 hour', timestamp) AS bucket,
+        subsystem_id,
+        COUNT(*) AS sample_count,
+        MIN(temperature) AS temperature_min,
+        MAX(temperature) AS temperature_max,
+        SUM(temperature::FLOAT8) AS temperature_sum,
+        SUM(temperature::FLOAT8 * temperature::FLOAT8) AS temperature_sumsq,
+        first(temperature, timestamp) AS temperature_first,
+        last(temperature, timestamp) AS temperature_last,
+        MIN(battery) AS battery_min,
+        MAX(battery) AS battery_max,
+        SUM(battery::FLOAT8) AS battery_sum,
+        SUM(battery::FLOAT8 * battery::FLOAT8) AS battery_sumsq,
+        first(battery, timestamp) AS battery_first,
+        last(battery, timestamp) AS battery_last,
+        MIN(altitude) AS altitude_min,
+        MAX(altitude) AS altitude_max,
+        SUM(altitude::FLOAT8) AS altitude_sum,
+        SUM(altitude::FLOAT8 * altitude::FLOAT8) AS altitude_sumsq,
+        first(altitude, timestamp) AS altitude_first,
+        last(altitude, timestamp) AS altitude_last,
+        MIN(signal) AS signal_min,
+        MAX(signal) AS signal_max,
+        SUM(signal::FLOAT8) AS signal_sum,
+        SUM(signal::FLOAT8 * signal::FLOAT8) AS signal_sumsq,
+        first(signal, timestamp) AS signal_first,
+        last(signal, timestamp) AS signal_last,
+        SUM(has_anomaly::INT) AS anomaly_count
+    FROM telemetry
+    GROUP BY bucket, subsystem_id
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('telemetry_1h',
+    start_offset => INTERVAL '3 days',
+    end_offset => INTERVAL '1 hour',
+    schedule_interval => INTERVAL '30 minutes'
+);
+
+CREATE MATERIALIZED VIEW telemetry_1d
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+    SELECT
+        time_bucket(INTERVAL '1 day', timestamp) AS bucket,
+        subsystem_id,
+        COUNT(*) AS sample_count,
+        MIN(temperature) AS temperature_min,
+        MAX(temperature) AS temperature_max,
+        SUM(temperature::FLOAT8) AS temperature_sum,
+        SUM(temperature::FLOAT8 * temperature::FLOAT8) AS temperature_sumsq,
+        first(temperature, timestamp) AS temperature_first,
+        last(temperature, timestamp) AS temperature_last,
+        MIN(battery) AS battery_min,
+        MAX(battery) AS battery_max,
+        SUM(battery::FLOAT8) AS battery_sum,
+        SUM(battery::FLOAT8 * battery::FLOAT8) AS battery_sumsq,
+        first(battery, timestamp) AS battery_first,
+        last(battery, timestamp) AS battery_last,
+        MIN(altitude) AS altitude_min,
+        MAX(altitude) AS altitude_max,
+        SUM(altitude::FLOAT8) AS altitude_sum,
+        SUM(altitude::FLOAT8 * altitude::FLOAT8) AS altitude_sumsq,
+        first(altitude, timestamp) AS altitude_first,
+        last(altitude, timestamp) AS altitude_last,
+        MIN(signal) AS signal_min,
+        MAX(signal) AS signal_max,
+        SUM(signal::FLOAT8) AS signal_sum,
+        SUM(signal::FLOAT8 * signal::FLOAT8) AS signal_sumsq,
+        first(signal, timestamp) AS signal_first,
+        last(signal, timestamp) AS signal_last,
+        SUM(has_anomaly::INT) AS anomaly_count
+    FROM telemetry
+    GROUP BY bucket, subsystem_id
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('telemetry_1d',
+    start_offset => INTERVAL '30 days',
+    end_offset => INTERVAL '1 day',
+    schedule_interval => INTERVAL '1 hour'
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_telemetry_subsystem_timestamp_id;
+DROP INDEX IF EXISTS idx_anomalies_subsystem_timestamp_id;
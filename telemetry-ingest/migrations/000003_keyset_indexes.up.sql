@@ -0,0 +1,7 @@
+-- Keyset pagination orders by (timestamp, id); including id lets the cursor
+-- predicate and tie-break be answered from the index alone.
+CREATE INDEX IF NOT EXISTS idx_telemetry_subsystem_timestamp_id
+    ON telemetry (subsystem_id, timestamp DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_subsystem_timestamp_id
+    ON anomalies (subsystem_id, timestamp DESC, id DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_telemetry_subsystem_temperature;
+DROP INDEX IF EXISTS idx_telemetry_subsystem_battery;
+DROP INDEX IF EXISTS idx_telemetry_subsystem_altitude;
+DROP INDEX IF EXISTS idx_telemetry_subsystem_signal;
+DROP INDEX IF EXISTS idx_telemetry_anomalous_timestamp_id;
@@ -0,0 +1,20 @@
+-- Value predicates (e.g. battery_lt=50) are evaluated within the hourly chunks
+-- selected by the time range; per-parameter indexes let each chunk answer them
+-- without a sequential scan.
+CREATE INDEX IF NOT EXISTS idx_telemetry_subsystem_temperature
+    ON telemetry (subsystem_id, temperature);
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_subsystem_battery
+    ON telemetry (subsystem_id, battery);
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_subsystem_altitude
+    ON telemetry (subsystem_id, altitude);
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_subsystem_signal
+    ON telemetry (subsystem_id, signal);
+
+-- has_anomaly=true is highly selective, so a partial index in keyset order
+-- serves it directly.
+CREATE INDEX IF NOT EXISTS idx_telemetry_anomalous_timestamp_id
+    ON telemetry (subsystem_id, timestamp DESC, id DESC)
+    WHERE has_anomaly;
@@ -0,0 +1,29 @@
+-- Asynchronous export jobs. The API's worker claims pending jobs, writes the
+-- file to local storage and records progress here so jobs outlive a restart.
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id TEXT PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'expired')),
+    request JSONB NOT NULL,
+    rows_estimated BIGINT NOT NULL DEFAULT 0,
+    rows_written BIGINT NOT NULL DEFAULT 0,
+    bytes_written BIGINT NOT NULL DEFAULT 0,
+    file_path TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    -- Running jobs are leased: the worker refreshes heartbeat_at while it
+    -- runs, and a job whose heartbeat goes stale is returned to the queue by
+    -- any instance.
+    heartbeat_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_pending
+    ON export_jobs (created_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires_at
+    ON export_jobs (expires_at)
+    WHERE expires_at IS NOT NULL;
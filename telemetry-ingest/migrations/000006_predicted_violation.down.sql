@@ -0,0 +1,22 @@
+-- Enum values cannot be dropped, so the type is rebuilt without them.
+DELETE FROM anomalies WHERE anomaly_type IN (
+    'predicted_temperature_violation',
+    'predicted_battery_violation',
+    'predicted_altitude_violation',
+    'predicted_signal_violation'
+);
+
+ALTER TYPE anomaly_type RENAME TO anomaly_type_old;
+
+CREATE TYPE anomaly_type AS ENUM (
+    'high_temperature',
+    'low_temperature',
+    'low_battery',
+    'low_altitude',
+    'weak_signal'
+);
+
+ALTER TABLE anomalies
+    ALTER COLUMN anomaly_type TYPE anomaly_type USING anomaly_type::text::anomaly_type;
+
+DROP TYPE anomaly_type_old;
@@ -0,0 +1,8 @@
+-- Raised by the forecaster when a parameter's trend is expected to leave its
+-- normal band within the configured horizon. There is one type per parameter,
+-- so a forecast anomaly maps to the parameter it was raised on like every
+-- other type.
+ALTER TYPE anomaly_type ADD VALUE IF NOT EXISTS 'predicted_temperature_violation';
+ALTER TYPE anomaly_type ADD VALUE IF NOT EXISTS 'predicted_battery_violation';
+ALTER TYPE anomaly_type ADD VALUE IF NOT EXISTS 'predicted_altitude_violation';
+ALTER TYPE anomaly_type ADD VALUE IF NOT EXISTS 'predicted_signal_violation';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_telemetry_created_at_id;
+DROP INDEX IF EXISTS idx_anomalies_created_at_id;
@@ -0,0 +1,8 @@
+-- Streams resume in (created_at, id) order: ids are assigned before commit,
+-- so a later id can become visible first, while created_at bounds how far
+-- back a late commit can land.
+CREATE INDEX IF NOT EXISTS idx_telemetry_created_at_id
+    ON telemetry (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_created_at_id
+    ON anomalies (created_at, id);